@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"slices"
+
+	"github.com/madmaxieee/loglit/internal/reader"
+	"github.com/madmaxieee/loglit/internal/utils"
+
+	"github.com/spf13/cobra"
+)
+
+var execFlags struct {
+	StderrPrefix string
+	PTY          bool
+}
+
+var execCmd = &cobra.Command{
+	Use:   "exec [patterns...] -- command [args...]",
+	Short: "Run a command and highlight its stdout and stderr",
+	Long: `Exec runs a command and highlights the lines it writes to stdout and
+stderr, like piping both into loglit. Arguments before -- are patterns, the
+ones after are the command. Without --, all arguments are the command. Flags
+of loglit go before the first argument, the ones after it are passed on to
+the command. A command with a -- of its own needs a -- before it.
+
+Signals sent to loglit are passed on to the command and loglit exits with
+its exit status once its output is consumed.
+
+With --pty, the command runs on a pseudo-terminal instead of pipes, so it
+keeps the line buffering and colors it uses on a terminal.`,
+
+	Args: func(cmd *cobra.Command, args []string) error {
+		patterns, command := splitExecArgs(args, cmd.ArgsLenAtDash())
+		if len(command) == 0 {
+			return fmt.Errorf("exec requires a command")
+		}
+		return parsePatternArgs(patterns)
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		_, command := splitExecArgs(args, cmd.ArgsLenAtDash())
+
+		hl := newHighlighting(cmd)
+
+		stderrPrefix := execFlags.StderrPrefix
+		if stderrPrefix != "" && !hl.renderer.NoColor {
+			if marker, ok := hl.theme.HighlightMap["LogStderr"]; ok {
+				stderrPrefix = marker.BuildAnsi() + stderrPrefix + marker.BuildAnsiReset()
+			}
+		}
+
+		out := newOutput(hl.isStderrTerminal)
+
+		child := exec.Command(command[0], command[1:]...)
+		var stdout, stderr io.Reader
+		var terminals *ptys
+		var err error
+		if execFlags.PTY {
+			terminals, err = openPTYs()
+			if err != nil {
+				utils.HandleError(err)
+			}
+			stdout, stderr = terminals.stdout, terminals.stderr
+			err = terminals.start(child)
+		} else {
+			child.Stdin = os.Stdin
+			stdout, err = child.StdoutPipe()
+			if err != nil {
+				utils.HandleError(err)
+			}
+			stderr, err = child.StderrPipe()
+			if err != nil {
+				utils.HandleError(err)
+			}
+			err = child.Start()
+		}
+		if err != nil {
+			out.close()
+			fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+			os.Exit(startExitStatus(err))
+		}
+		stopForwarding := forwardSignals(child.Process, execFlags.PTY)
+
+		// lines of both streams go to the raw output, as with cmd 2>&1 | loglit,
+		// only the colored output marks the stderr lines
+		sources := []*source{
+			{name: "stdout", reader: stdout, lb: hl.newLineBuffer("")},
+			{name: "stderr", reader: stderr, lb: hl.newLineBuffer(stderrPrefix)},
+		}
+
+		// partial lines would interleave with the complete lines of the other
+		// stream, so only complete lines are flushed, except for the prompts
+		// of interactive commands on a terminal
+		var partial *reader.LineBuffer
+		if execFlags.PTY {
+			partial = sources[0].lb
+		}
+		stopFlushing := out.flushPeriodically(partial)
+		copyErr := out.copySources(sources)
+		stopFlushing()
+
+		// the exit status is in the process state, Wait only fails on errors
+		// copying output, which the pipes rule out
+		_ = child.Wait()
+		// closing the terminals hangs up the command, so only once it exited
+		if terminals != nil {
+			terminals.close()
+		}
+		stopForwarding()
+		out.close()
+		status := exitStatus(child.ProcessState)
+		if copyErr != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", copyErr.Error())
+			// the output is incomplete even if the command succeeded
+			status = max(status, 1)
+		}
+		os.Exit(status)
+	},
+}
+
+// splitExecArgs splits the arguments of exec into patterns and the command.
+// Flags stop at the first argument, so a -- after patterns is still in args
+// and dash, the position of a -- before any argument, is -1. Without --, all
+// arguments are the command.
+func splitExecArgs(args []string, dash int) ([]string, []string) {
+	if dash >= 0 {
+		return args[:dash], args[dash:]
+	}
+	i := slices.Index(args, "--")
+	if i < 0 {
+		return nil, args
+	}
+	return args[:i], args[i+1:]
+}
+
+// startExitStatus returns the exit status a shell would for a command that
+// could not be started: 127 if it was not found, 126 otherwise.
+func startExitStatus(err error) int {
+	if errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist) {
+		return 127
+	}
+	return 126
+}
+
+func init() {
+	execCmd.Flags().StringVar(&execFlags.StderrPrefix, "stderr-prefix", "", "Prefix for lines the command writes to stderr, e.g. \"[stderr] \", colored with the LogStderr group")
+	execCmd.Flags().BoolVar(&execFlags.PTY, "pty", false, "Run the command on a pseudo-terminal, so it keeps the line buffering and colors it uses on a terminal, Linux only")
+	// flags after the first argument belong to the command
+	execCmd.Flags().SetInterspersed(false)
+	rootCmd.AddCommand(execCmd)
+}
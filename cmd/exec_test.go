@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"os/exec"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestExecArgs(t *testing.T) {
+	defer func() { execFlags.StderrPrefix = "" }()
+
+	tests := []struct {
+		args     []string
+		patterns []string
+		command  []string
+	}{
+		{[]string{"ls", "-la", "/tmp"}, nil, []string{"ls", "-la", "/tmp"}},
+		{[]string{"--stderr-prefix", "E ", "make", "-j4"}, nil, []string{"make", "-j4"}},
+		{[]string{"--", "ls", "-la"}, []string{}, []string{"ls", "-la"}},
+		{[]string{"ERR", "WARN", "--", "make", "-j4"}, []string{"ERR", "WARN"}, []string{"make", "-j4"}},
+		{[]string{"--stderr-prefix", "E ", "ERR", "--", "go", "test", "--", "-v"}, []string{"ERR"}, []string{"go", "test", "--", "-v"}},
+		{[]string{"--", "go", "test", "--", "-v"}, []string{}, []string{"go", "test", "--", "-v"}},
+		{[]string{"ERR", "--"}, []string{"ERR"}, []string{}},
+	}
+	for _, tt := range tests {
+		// the position of -- is kept from earlier parses otherwise
+		execCmd.Flags().Init(execCmd.Name(), pflag.ContinueOnError)
+		if err := execCmd.ParseFlags(tt.args); err != nil {
+			t.Errorf("failed to parse %q: %v", tt.args, err)
+			continue
+		}
+		patterns, command := splitExecArgs(execCmd.Flags().Args(), execCmd.ArgsLenAtDash())
+		if !reflect.DeepEqual(patterns, tt.patterns) || !reflect.DeepEqual(command, tt.command) {
+			t.Errorf("arguments %q split into %q, %q, expected %q, %q", tt.args, patterns, command, tt.patterns, tt.command)
+		}
+	}
+}
+
+func TestExitStatus(t *testing.T) {
+	err := exec.Command("loglit-no-such-command").Start()
+	if status := startExitStatus(err); status != 127 {
+		t.Errorf("expected 127 for a missing command, got %d", status)
+	}
+
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not found")
+	}
+	for script, expected := range map[string]int{
+		"exit 0":        0,
+		"exit 3":        3,
+		"kill -TERM $$": 143,
+	} {
+		child := exec.Command(sh, "-c", script)
+		_ = child.Run()
+		if status := exitStatus(child.ProcessState); status != expected {
+			t.Errorf("expected status %d for %q, got %d", expected, script, status)
+		}
+	}
+}
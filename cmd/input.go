@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/madmaxieee/loglit/internal/reader"
+	"github.com/madmaxieee/loglit/internal/style"
+)
+
+// input is a log source, either a file or stdin when path is empty.
+type input struct {
+	name string
+	path string
+}
+
+var inputLabelRe = regexp.MustCompile(`^([\w.-]+)=(.+)$`)
+
+// expandInputs turns --input values into inputs. Values may be globs and may
+// be labeled as name=path, otherwise inputs are named after the file.
+func expandInputs(values []string) ([]input, error) {
+	if len(values) == 0 {
+		return []input{{name: "stdin"}}, nil
+	}
+
+	var inputs []input
+	for _, value := range values {
+		label, pattern := "", value
+		if m := inputLabelRe.FindStringSubmatch(value); m != nil {
+			if _, err := os.Stat(value); err != nil {
+				label, pattern = m[1], m[2]
+			}
+		}
+
+		paths := []string{pattern}
+		if strings.ContainsAny(pattern, "*?[") {
+			matches, err := filepath.Glob(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid input pattern '%s': %v", pattern, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no input files match '%s'", pattern)
+			}
+			paths = matches
+		}
+
+		for _, path := range paths {
+			name := label
+			if name == "" {
+				name = filepath.Base(path)
+			}
+			inputs = append(inputs, input{name: name, path: path})
+		}
+	}
+	return inputs, nil
+}
+
+// open opens the input for reading, following it if requested.
+func (in input) open(follow bool, lines int) (io.ReadCloser, error) {
+	if in.path == "" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	if follow {
+		return reader.NewFollower(in.path, lines)
+	}
+	return os.Open(in.path)
+}
+
+// sourceColors assigns each name a highlight from the palette. Colors are
+// picked by hashing the name so a source keeps its color across runs, moving
+// on to the next free color on collisions while the palette has room.
+func sourceColors(names []string, palette []*style.Highlight) map[string]*style.Highlight {
+	colors := make(map[string]*style.Highlight)
+	if len(palette) == 0 {
+		return colors
+	}
+	used := make([]bool, len(palette))
+	for _, name := range names {
+		if _, ok := colors[name]; ok {
+			continue
+		}
+		h := fnv.New32a()
+		h.Write([]byte(name))
+		idx := int(h.Sum32() % uint32(len(palette)))
+		for i := 0; i < len(palette) && used[idx]; i++ {
+			idx = (idx + 1) % len(palette)
+		}
+		used[idx] = true
+		colors[name] = palette[idx]
+	}
+	return colors
+}
+
+// sourcePrefixes formats the prefix for each input from format, replacing
+// {name} with the input name. Prefixes are padded to the same width so the
+// lines after them stay aligned.
+func sourcePrefixes(inputs []input, format string) []string {
+	prefixes := make([]string, len(inputs))
+	width := 0
+	for i, in := range inputs {
+		prefixes[i] = strings.ReplaceAll(format, "{name}", in.name)
+		width = max(width, len(prefixes[i]))
+	}
+	for i := range prefixes {
+		prefixes[i] += strings.Repeat(" ", width-len(prefixes[i]))
+	}
+	return prefixes
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/madmaxieee/loglit/internal/style"
+)
+
+func TestExpandInputs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"api.log", "worker.log", "a=b.log"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	inputs, err := expandInputs([]string{
+		filepath.Join(dir, "*er.log"),
+		"svc=" + filepath.Join(dir, "api.log"),
+		filepath.Join(dir, "a=b.log"),
+	})
+	if err != nil {
+		t.Fatalf("failed to expand inputs: %v", err)
+	}
+
+	expected := []input{
+		{name: "worker.log", path: filepath.Join(dir, "worker.log")},
+		{name: "svc", path: filepath.Join(dir, "api.log")},
+		{name: "a=b.log", path: filepath.Join(dir, "a=b.log")},
+	}
+	if !reflect.DeepEqual(inputs, expected) {
+		t.Errorf("expected %+v, got %+v", expected, inputs)
+	}
+
+	if _, err := expandInputs([]string{filepath.Join(dir, "*.txt")}); err == nil {
+		t.Error("expected an error for a glob without matches")
+	}
+}
+
+func TestSourceColors(t *testing.T) {
+	palette := []*style.Highlight{{Group: "A"}, {Group: "B"}, {Group: "C"}}
+	names := []string{"api", "worker", "db"}
+
+	colors := sourceColors(names, palette)
+	seen := make(map[*style.Highlight]bool)
+	for _, name := range names {
+		hl := colors[name]
+		if hl == nil {
+			t.Fatalf("no color assigned to %q", name)
+		}
+		if seen[hl] {
+			t.Errorf("color %s assigned to more than one source", hl.Group)
+		}
+		seen[hl] = true
+	}
+
+	if again := sourceColors(names, palette); !reflect.DeepEqual(colors, again) {
+		t.Error("expected colors to be stable")
+	}
+}
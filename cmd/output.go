@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/madmaxieee/loglit/internal/reader"
+	"github.com/madmaxieee/loglit/internal/utils"
+
+	"golang.org/x/term"
+)
+
+// output holds the writers of the colored output on stderr and the raw output
+// on stdout or the output file. Writers must hold mu.
+type output struct {
+	mu      sync.Mutex
+	colored *bufio.Writer
+	raw     *bufio.Writer
+	file    *os.File
+	// whether partial lines are flushed to the colored output
+	coloredPartial bool
+}
+
+// newOutput sets up the writers from the output flags. coloredPartial is
+// whether partial lines are shown on the colored output, which only makes
+// sense on a terminal.
+func newOutput(coloredPartial bool) *output {
+	out := &output{
+		colored:        bufio.NewWriter(os.Stderr),
+		coloredPartial: coloredPartial,
+	}
+
+	if flags.OutputFile == "" {
+		if !term.IsTerminal(int(os.Stdout.Fd())) {
+			out.raw = bufio.NewWriter(os.Stdout)
+		} else {
+			out.raw = bufio.NewWriter(io.Discard)
+		}
+		return out
+	}
+
+	openFlag := os.O_CREATE | os.O_WRONLY
+	if flags.AppendMode {
+		openFlag |= os.O_APPEND
+	} else {
+		openFlag |= os.O_TRUNC
+	}
+	file, err := os.OpenFile(flags.OutputFile, openFlag, 0644)
+	if err != nil {
+		utils.HandleError(err)
+	}
+	out.file = file
+	out.raw = bufio.NewWriter(file)
+	return out
+}
+
+func (out *output) flushLocked() {
+	out.colored.Flush()
+	out.raw.Flush()
+}
+
+// flushPartial writes the partial line of lb, if lb is not nil.
+func (out *output) flushPartial(lb *reader.LineBuffer) {
+	if lb == nil {
+		return
+	}
+	if out.coloredPartial {
+		lb.FlushPending(out.colored, out.raw)
+	} else {
+		lb.FlushPending(nil, out.raw)
+	}
+}
+
+// close flushes the writers and closes the output file.
+func (out *output) close() {
+	out.mu.Lock()
+	out.flushLocked()
+	out.mu.Unlock()
+	if out.file != nil {
+		out.file.Close()
+	}
+}
+
+// flushPeriodically flushes the writers, along with the partial line of
+// partial if it is not nil, until the returned function is called.
+func (out *output) flushPeriodically(partial *reader.LineBuffer) func() {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				out.mu.Lock()
+				out.flushPartial(partial)
+				out.flushLocked()
+				out.mu.Unlock()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
+
+// copySources reads the sources concurrently and writes their lines until
+// all of them are exhausted. It returns the errors reading them, naming the
+// source of each.
+func (out *output) copySources(sources []*source) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(sources))
+	for i, src := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			read := reader.ReadChunks
+			if src.batched {
+				read = reader.ReadBatches
+			}
+			chunks, readErr := read(src.reader)
+			for chunk := range chunks {
+				out.mu.Lock()
+				src.lb.Append(chunk)
+				src.lb.ProcessCompleteLines(out.colored, out.raw)
+				out.mu.Unlock()
+			}
+
+			out.mu.Lock()
+			src.lb.Finalize(out.colored, out.raw)
+			out.mu.Unlock()
+			if err := readErr(); err != nil {
+				errs[i] = src.readError(err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
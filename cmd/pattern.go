@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/madmaxieee/loglit/internal/proto"
+	"github.com/madmaxieee/loglit/internal/style"
+)
+
+// userPattern is a regex or, with --fixed-strings, a literal given on the
+// command line with an optional style.
+type userPattern struct {
+	regexp  *regexp.Regexp
+	literal string
+	style   *style.HighlightSpec
+}
+
+// parsePatternArg parses a pattern argument, which may end in @ followed by a
+// style such as `ERR-\d+@red,bold`. A suffix that is not a valid style is
+// part of the pattern, and `\@` can be used in a regex to match a literal @
+// before something that looks like a style.
+func parsePatternArg(arg string, fixed bool) (userPattern, error) {
+	pattern := arg
+	var spec *style.HighlightSpec
+	if idx := strings.LastIndex(arg, "@"); idx > 0 && (fixed || arg[idx-1] != '\\') {
+		if s, err := style.ParseSpec(arg[idx+1:]); err == nil {
+			pattern = arg[:idx]
+			spec = &s
+		}
+	}
+	if fixed {
+		return userPattern{literal: pattern, style: spec}, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return userPattern{}, fmt.Errorf("invalid regex pattern '%s': %v", pattern, err)
+	}
+	return userPattern{regexp: re, style: spec}, nil
+}
+
+// readPatternsFile returns the non-empty lines of the file at path.
+func readPatternsFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var patterns []string
+	for line := range strings.Lines(string(data)) {
+		line = strings.TrimRight(line, "\r\n")
+		if line != "" {
+			patterns = append(patterns, line)
+		}
+	}
+	return patterns, nil
+}
+
+// applyPatternStyles sets the style of the i-th pattern to the i-th style,
+// overriding inline styles.
+func applyPatternStyles(patterns []userPattern, styles []string) error {
+	if len(styles) > len(patterns) {
+		return fmt.Errorf("got %d pattern styles for %d patterns", len(styles), len(patterns))
+	}
+	for i, s := range styles {
+		spec, err := style.ParseSpec(s)
+		if err != nil {
+			return err
+		}
+		patterns[i].style = &spec
+	}
+	return nil
+}
+
+// userPatternSyntax returns the syntax for the patterns along with the
+// highlights of styled patterns. Patterns without a style rotate through the
+// palette, falling back to the UserPattern group if it is empty.
+func userPatternSyntax(patterns []userPattern, palette []*style.Highlight) ([]proto.Syntax, []style.Highlight) {
+	var syntax []proto.Syntax
+	var highlights []style.Highlight
+	for i, p := range patterns {
+		group := "UserPattern"
+		switch {
+		case p.style != nil:
+			spec := *p.style
+			spec.Group = fmt.Sprintf("UserPatternStyle%d", i+1)
+			highlights = append(highlights, spec.Highlight())
+			group = spec.Group
+		case len(palette) > 0:
+			group = palette[i%len(palette)].Group
+		}
+		syn := proto.Syntax{Group: group}
+		if p.regexp != nil {
+			syn.Pattern = proto.Pattern{Regexp: p.regexp}
+		} else {
+			syn.Literals = []string{p.literal}
+		}
+		syntax = append(syntax, syn)
+	}
+	return syntax, highlights
+}
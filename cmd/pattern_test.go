@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/madmaxieee/loglit/internal/style"
+)
+
+func TestParsePatternArg(t *testing.T) {
+	tests := []struct {
+		arg    string
+		regex  string
+		styled bool
+	}{
+		{arg: `ERR-\d+@red,bold`, regex: `ERR-\d+`, styled: true},
+		{arg: `user@example\.com`, regex: `user@example\.com`},
+		{arg: `user\@red`, regex: `user\@red`},
+		{arg: `@red`, regex: `@red`},
+		{arg: `timeout`, regex: `timeout`},
+	}
+	for _, tt := range tests {
+		p, err := parsePatternArg(tt.arg, false)
+		if err != nil {
+			t.Errorf("parsePatternArg(%q) failed: %v", tt.arg, err)
+			continue
+		}
+		if p.regexp.String() != tt.regex || (p.style != nil) != tt.styled {
+			t.Errorf("parsePatternArg(%q) = %q styled %v, want %q styled %v", tt.arg, p.regexp.String(), p.style != nil, tt.regex, tt.styled)
+		}
+	}
+
+	if _, err := parsePatternArg(`(@red`, false); err == nil {
+		t.Error("expected an error for an invalid regex")
+	}
+}
+
+func TestParsePatternArgFixed(t *testing.T) {
+	p, err := parsePatternArg(`[main](@red`, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.regexp != nil || p.literal != "[main](" || p.style == nil {
+		t.Errorf("unexpected pattern %+v", p)
+	}
+
+	syntax, _ := userPatternSyntax([]userPattern{p}, nil)
+	if len(syntax[0].Literals) != 1 || syntax[0].Pattern.HasValue() {
+		t.Errorf("expected a literal syntax, got %+v", syntax[0])
+	}
+}
+
+func TestReadPatternsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "patterns.txt")
+	if err := os.WriteFile(path, []byte("cust-1\r\n\ncust-2\n"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	patterns, err := readPatternsFile(path)
+	if err != nil {
+		t.Fatalf("readPatternsFile failed: %v", err)
+	}
+	if !reflect.DeepEqual(patterns, []string{"cust-1", "cust-2"}) {
+		t.Errorf("unexpected patterns %q", patterns)
+	}
+}
+
+func TestUserPatternSyntax(t *testing.T) {
+	var patterns []userPattern
+	for _, arg := range []string{"a", "b@blue", "c", "d"} {
+		p, err := parsePatternArg(arg, false)
+		if err != nil {
+			t.Fatalf("parsePatternArg(%q) failed: %v", arg, err)
+		}
+		patterns = append(patterns, p)
+	}
+	if err := applyPatternStyles(patterns, []string{"green,underline"}); err != nil {
+		t.Fatalf("applyPatternStyles failed: %v", err)
+	}
+	if err := applyPatternStyles(patterns, make([]string, 5)); err == nil {
+		t.Error("expected an error for more styles than patterns")
+	}
+
+	palette := []*style.Highlight{{Group: "UserPattern1"}, {Group: "UserPattern2"}}
+	syntax, highlights := userPatternSyntax(patterns, palette)
+	expectedGroups := []string{"UserPatternStyle1", "UserPatternStyle2", "UserPattern1", "UserPattern2"}
+	for i, syn := range syntax {
+		if syn.Group != expectedGroups[i] {
+			t.Errorf("pattern %d: expected group %q, got %q", i, expectedGroups[i], syn.Group)
+		}
+	}
+	if len(highlights) != 2 || !highlights[0].Underline || *highlights[1].Fg != "#0000EE" {
+		t.Errorf("unexpected highlights %+v", highlights)
+	}
+
+	syntax, _ = userPatternSyntax(patterns[2:], nil)
+	if syntax[0].Group != "UserPattern" {
+		t.Errorf("expected UserPattern without a palette, got %q", syntax[0].Group)
+	}
+}
@@ -0,0 +1,157 @@
+//go:build linux
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// pty is a pseudo-terminal, the command writes to tty and loglit reads what
+// it wrote from master.
+type pty struct {
+	master *os.File
+	tty    *os.File
+}
+
+func openPTY() (*pty, error) {
+	master, err := unix.Open("/dev/ptmx", unix.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a pseudo-terminal: %w", err)
+	}
+	p := &pty{master: os.NewFile(uintptr(master), "/dev/ptmx")}
+
+	if err := unix.IoctlSetPointerInt(master, unix.TIOCSPTLCK, 0); err != nil {
+		p.close()
+		return nil, fmt.Errorf("failed to unlock the pseudo-terminal: %w", err)
+	}
+	n, err := unix.IoctlGetUint32(master, unix.TIOCGPTN)
+	if err != nil {
+		p.close()
+		return nil, fmt.Errorf("failed to get the pseudo-terminal number: %w", err)
+	}
+	name := fmt.Sprintf("/dev/pts/%d", n)
+	tty, err := unix.Open(name, unix.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		p.close()
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	p.tty = os.NewFile(uintptr(tty), name)
+
+	// loglit's own terminal already echoes the input, and the output is
+	// passed on with the line endings the command wrote
+	termios, err := unix.IoctlGetTermios(tty, unix.TCGETS)
+	if err == nil {
+		termios.Lflag &^= unix.ECHO
+		termios.Oflag &^= unix.ONLCR
+		err = unix.IoctlSetTermios(tty, unix.TCSETS, termios)
+	}
+	if err != nil {
+		p.close()
+		return nil, fmt.Errorf("failed to set up %s: %w", name, err)
+	}
+	return p, nil
+}
+
+// Read reads what the command wrote to the terminal. Reading the master of a
+// terminal closed by the command fails with EIO, which is the end of its
+// output.
+func (p *pty) Read(b []byte) (int, error) {
+	n, err := p.master.Read(b)
+	if errors.Is(err, unix.EIO) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (p *pty) close() {
+	p.master.Close()
+	if p.tty != nil {
+		p.tty.Close()
+	}
+}
+
+// ptys are the pseudo-terminals of a command run by exec --pty. stdin and
+// stdout share one, stderr has its own so its lines can still be told apart.
+type ptys struct {
+	stdout *pty
+	stderr *pty
+	winch  chan os.Signal
+}
+
+func openPTYs() (*ptys, error) {
+	stdout, err := openPTY()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := openPTY()
+	if err != nil {
+		stdout.close()
+		return nil, err
+	}
+	return &ptys{stdout: stdout, stderr: stderr}, nil
+}
+
+// start starts child on the pseudo-terminals, in a new session with the
+// stdout one as its controlling terminal. The input of loglit is copied to
+// the command and window size changes of loglit's terminal are passed on.
+func (p *ptys) start(child *exec.Cmd) error {
+	child.Stdin = p.stdout.tty
+	child.Stdout = p.stdout.tty
+	child.Stderr = p.stderr.tty
+	child.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true, Ctty: 0}
+
+	p.resize()
+	p.winch = make(chan os.Signal, 1)
+	signal.Notify(p.winch, syscall.SIGWINCH)
+	go func() {
+		for range p.winch {
+			p.resize()
+		}
+	}()
+
+	if err := child.Start(); err != nil {
+		return err
+	}
+	// the masters only reach the end of the output once the command and all
+	// of its children closed the terminals
+	p.stdout.tty.Close()
+	p.stderr.tty.Close()
+
+	go func() {
+		_, _ = io.Copy(p.stdout.master, os.Stdin)
+		// end of file, as typed at a terminal
+		_, _ = p.stdout.master.Write([]byte{4})
+	}()
+	return nil
+}
+
+// resize sets the window size of the pseudo-terminals to the one of loglit's
+// terminal, if it has one.
+func (p *ptys) resize() {
+	for _, f := range []*os.File{os.Stdin, os.Stdout, os.Stderr} {
+		size, err := unix.IoctlGetWinsize(int(f.Fd()), unix.TIOCGWINSZ)
+		if err != nil {
+			continue
+		}
+		_ = unix.IoctlSetWinsize(int(p.stdout.master.Fd()), unix.TIOCSWINSZ, size)
+		_ = unix.IoctlSetWinsize(int(p.stderr.master.Fd()), unix.TIOCSWINSZ, size)
+		return
+	}
+}
+
+func (p *ptys) close() {
+	if p.winch != nil {
+		signal.Stop(p.winch)
+		close(p.winch)
+	}
+	p.stdout.close()
+	p.stderr.close()
+}
@@ -0,0 +1,45 @@
+//go:build linux
+
+package cmd
+
+import (
+	"io"
+	"os/exec"
+	"testing"
+)
+
+func TestPTYs(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not found")
+	}
+	terminals, err := openPTYs()
+	if err != nil {
+		t.Skipf("pseudo-terminals are not available: %v", err)
+	}
+
+	child := exec.Command(sh, "-c", "test -t 0 && test -t 1 && test -t 2 && echo out && echo err >&2")
+	if err := terminals.start(child); err != nil {
+		t.Fatalf("failed to start command: %v", err)
+	}
+	stdout, err := io.ReadAll(terminals.stdout)
+	if err != nil {
+		t.Errorf("failed to read stdout: %v", err)
+	}
+	stderr, err := io.ReadAll(terminals.stderr)
+	if err != nil {
+		t.Errorf("failed to read stderr: %v", err)
+	}
+	err = child.Wait()
+	terminals.close()
+	if err != nil {
+		t.Fatalf("expected the command to run on terminals, got %v", err)
+	}
+
+	if string(stdout) != "out\n" {
+		t.Errorf("expected stdout %q, got %q", "out\n", stdout)
+	}
+	if string(stderr) != "err\n" {
+		t.Errorf("expected stderr %q, got %q", "err\n", stderr)
+	}
+}
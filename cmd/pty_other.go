@@ -0,0 +1,32 @@
+//go:build !linux
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+type pty struct {
+	master *os.File
+}
+
+func (p *pty) Read(b []byte) (int, error) {
+	return p.master.Read(b)
+}
+
+type ptys struct {
+	stdout *pty
+	stderr *pty
+}
+
+func openPTYs() (*ptys, error) {
+	return nil, fmt.Errorf("--pty is only supported on Linux")
+}
+
+func (p *ptys) start(child *exec.Cmd) error {
+	return fmt.Errorf("--pty is only supported on Linux")
+}
+
+func (p *ptys) close() {}
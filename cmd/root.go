@@ -1,22 +1,25 @@
 package cmd
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/signal"
-	"regexp"
+	"runtime"
 	"runtime/pprof"
-	"sync"
+	"slices"
+	"strings"
 	"syscall"
-	"time"
 
 	"github.com/madmaxieee/loglit/internal/config"
-	"github.com/madmaxieee/loglit/internal/proto"
+	"github.com/madmaxieee/loglit/internal/level"
+	"github.com/madmaxieee/loglit/internal/pretty"
 	"github.com/madmaxieee/loglit/internal/reader"
 	"github.com/madmaxieee/loglit/internal/renderer"
+	"github.com/madmaxieee/loglit/internal/style"
 	"github.com/madmaxieee/loglit/internal/theme"
+	"github.com/madmaxieee/loglit/internal/timestamp"
 	"github.com/madmaxieee/loglit/internal/utils"
 
 	"github.com/spf13/cobra"
@@ -24,13 +27,43 @@ import (
 )
 
 var flags struct {
-	InputFile  string
+	InputFiles []string
 	OutputFile string
 	AppendMode bool
 	Profile    string
+	ConfigFile string
+	Theme      string
+	ColorDepth string
+	Color      string
+
+	Filter        bool
+	InvertMatch   bool
+	AfterContext  int
+	BeforeContext int
+	Context       int
+
+	Level        string
+	LevelUnknown string
+
+	Follow       bool
+	Lines        int
+	PrefixFormat string
+	MergeByTime  bool
+	Jobs         int
+
+	Pretty         bool
+	PrettyTemplate string
+	Logfmt         bool
+
+	Rainbow       []string
+	PatternStyles []string
+	IgnoreCase    bool
+	WholeWord     bool
+	FixedStrings  bool
+	PatternsFile  string
 }
 
-var patternsFromArgs []regexp.Regexp
+var patternsFromArgs []userPattern
 
 var rootCmd = &cobra.Command{
 	Use:   "loglit",
@@ -40,17 +73,7 @@ based on built-in patterns and user-provided regex patterns. It is designed
 to make log analysis easier in the terminal.`,
 
 	Args: func(cmd *cobra.Command, args []string) error {
-		for _, arg := range args {
-			if arg == "" {
-				continue
-			}
-			pattern, err := regexp.Compile(arg)
-			if err != nil {
-				return fmt.Errorf("invalid regex pattern '%s': %v", arg, err)
-			}
-			patternsFromArgs = append(patternsFromArgs, *pattern)
-		}
-		return nil
+		return parsePatternArgs(args)
 	},
 
 	Run: func(cmd *cobra.Command, args []string) {
@@ -68,88 +91,77 @@ to make log analysis easier in the terminal.`,
 			defer println("CPU profiling data written to", flags.Profile)
 		}
 
-		cfg := config.GetDefaultConfig()
-		th := theme.GetDefaultTheme()
-
-		for _, pattern := range patternsFromArgs {
-			cfg.UserSyntax = append(cfg.UserSyntax, proto.Syntax{
-				Group:   "UserPattern",
-				Pattern: proto.Pattern{Regexp: &pattern},
-			})
+		if flags.Follow && len(flags.InputFiles) == 0 {
+			utils.HandleError(fmt.Errorf("--follow requires an input file"))
+		}
+		if flags.Follow && flags.MergeByTime {
+			utils.HandleError(fmt.Errorf("--merge-by-time cannot be used with --follow"))
+		}
+		if flags.Jobs < 1 {
+			utils.HandleError(fmt.Errorf("--jobs must be at least 1"))
 		}
 
-		renderer, err := renderer.New(cfg, th)
+		inputs, err := expandInputs(flags.InputFiles)
 		if err != nil {
 			utils.HandleError(err)
 		}
 
-		var inputReader io.Reader
-		if flags.InputFile == "" {
-			inputReader = os.Stdin
-		} else {
-			file, err := os.Open(flags.InputFile)
-			if err != nil {
-				utils.HandleError(err)
+		hl := newHighlighting(cmd)
+
+		prefixes := make([]string, len(inputs))
+		if len(inputs) > 1 || cmd.Flags().Changed("prefix-format") {
+			prefixes = sourcePrefixes(inputs, flags.PrefixFormat)
+			if !hl.renderer.NoColor {
+				names := make([]string, len(inputs))
+				for i, in := range inputs {
+					names[i] = in.name
+				}
+				colors := sourceColors(names, hl.theme.Palette("LogSource"))
+				for i, in := range inputs {
+					if color, ok := colors[in.name]; ok {
+						prefixes[i] = color.BuildAnsi() + prefixes[i] + color.BuildAnsiReset()
+					}
+				}
 			}
-			defer file.Close()
-			inputReader = file
 		}
-		bufferedInput := bufio.NewReader(inputReader)
 
-		outputWriter := bufio.NewWriter(os.Stderr)
+		out := newOutput(hl.isStderrTerminal)
+		defer out.close()
 
-		var rawOutputWriter *bufio.Writer
-		if flags.OutputFile == "" {
-			if !term.IsTerminal(int(os.Stdout.Fd())) {
-				rawOutputWriter = bufio.NewWriter(os.Stdout)
-			} else {
-				rawOutputWriter = bufio.NewWriter(io.Discard)
-			}
-		} else {
-			openFlag := os.O_CREATE | os.O_WRONLY
-			if flags.AppendMode {
-				openFlag |= os.O_APPEND
-			} else {
-				openFlag |= os.O_TRUNC
-			}
-			file, err := os.OpenFile(flags.OutputFile, openFlag, 0644)
+		// Streams are rendered line by line as they arrive, files are read in
+		// large batches whose lines are rendered in parallel.
+		isStream := len(flags.InputFiles) == 0 || flags.Follow
+
+		sources := make([]*source, len(inputs))
+		for i, in := range inputs {
+			r, err := in.open(flags.Follow, flags.Lines)
 			if err != nil {
 				utils.HandleError(err)
 			}
-			defer file.Close()
-			rawOutputWriter = bufio.NewWriter(file)
-		}
+			defer r.Close()
 
-		isStderrTerminal := term.IsTerminal(int(os.Stderr.Fd()))
+			lb := hl.newLineBuffer(prefixes[i])
+			if !isStream {
+				lb.SetJobs(flags.Jobs)
+			}
+			name := in.path
+			if name == "" {
+				name = in.name
+			}
+			sources[i] = &source{name: name, reader: reader.Decompress(r), lb: lb, batched: !isStream}
+		}
 
-		var outputMu sync.Mutex
-		defer func() {
-			outputMu.Lock()
-			outputWriter.Flush()
-			rawOutputWriter.Flush()
-			outputMu.Unlock()
-		}()
+		// Only a single input can show partial lines, with several inputs they
+		// would interleave with the complete lines of the others.
+		var partial *reader.LineBuffer
+		if len(sources) == 1 {
+			partial = sources[0].lb
+		}
 
-		chunkCh := reader.ReadChunks(bufferedInput)
-		lb := reader.NewLineBuffer(renderer)
-
-		// Flush periodically to ensure timely output for real-time streams, only when reading from stdin
-		if flags.InputFile == "" {
-			ticker := time.NewTicker(500 * time.Millisecond)
-			defer ticker.Stop()
-			go func() {
-				for range ticker.C {
-					outputMu.Lock()
-					if isStderrTerminal {
-						lb.FlushPending(outputWriter, rawOutputWriter)
-					} else {
-						lb.FlushPending(nil, rawOutputWriter)
-					}
-					outputWriter.Flush()
-					rawOutputWriter.Flush()
-					outputMu.Unlock()
-				}
-			}()
+		// Flush periodically to ensure timely output for real-time streams, only when reading from stdin or following a file
+		if isStream {
+			stop := out.flushPeriodically(partial)
+			defer stop()
 		}
 
 		// Handle interrupt signal to flush output before exiting
@@ -157,31 +169,227 @@ to make log analysis easier in the terminal.`,
 		signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 		go func() {
 			<-c
-			outputMu.Lock()
-			if isStderrTerminal {
-				lb.FlushPending(outputWriter, rawOutputWriter)
-			} else {
-				lb.FlushPending(nil, rawOutputWriter)
-			}
-			outputWriter.Flush()
-			rawOutputWriter.Flush()
-			outputMu.Unlock()
+			out.mu.Lock()
+			out.flushPartial(partial)
+			out.flushLocked()
+			out.mu.Unlock()
 			os.Exit(0)
 		}()
 
-		for chunk := range chunkCh {
-			outputMu.Lock()
-			lb.Append(chunk)
-			lb.ProcessCompleteLines(outputWriter, rawOutputWriter)
-			outputMu.Unlock()
+		if flags.MergeByTime {
+			readers := make([]io.Reader, len(sources))
+			for i, src := range sources {
+				readers[i] = src.reader
+			}
+			parser := timestamp.NewParser(config.DefaultConfig.BuiltInSyntax)
+			err := reader.MergeByTime(readers, parser.Parse, func(i int, entry []byte) {
+				out.mu.Lock()
+				sources[i].lb.Append(entry)
+				sources[i].lb.ProcessCompleteLines(out.colored, out.raw)
+				out.mu.Unlock()
+			})
+			out.mu.Lock()
+			for _, src := range sources {
+				src.lb.Finalize(out.colored, out.raw)
+			}
+			out.mu.Unlock()
+			var srcErr *reader.SourceError
+			if errors.As(err, &srcErr) {
+				err = sources[srcErr.Source].readError(srcErr.Err)
+			}
+			if err != nil {
+				out.close()
+				utils.HandleError(err)
+			}
+			return
 		}
 
-		outputMu.Lock()
-		lb.Finalize(outputWriter, rawOutputWriter)
-		outputMu.Unlock()
+		if err := out.copySources(sources); err != nil {
+			out.close()
+			utils.HandleError(err)
+		}
 	},
 }
 
+// parsePatternArgs parses pattern arguments, along with the patterns of
+// --patterns-file, into patternsFromArgs.
+func parsePatternArgs(args []string) error {
+	if flags.PatternsFile != "" {
+		filePatterns, err := readPatternsFile(flags.PatternsFile)
+		if err != nil {
+			return err
+		}
+		args = append(args, filePatterns...)
+	}
+	for _, arg := range args {
+		if arg == "" {
+			continue
+		}
+		pattern, err := parsePatternArg(arg, flags.FixedStrings)
+		if err != nil {
+			return err
+		}
+		patternsFromArgs = append(patternsFromArgs, pattern)
+	}
+	return nil
+}
+
+// highlighting is the renderer and line processing set up from the flags,
+// shared by all commands.
+type highlighting struct {
+	renderer    *renderer.Renderer
+	theme       theme.Theme
+	filter      *reader.Filter
+	levelFilter *level.Filter
+	formatter   *pretty.Formatter
+	// whether stderr, where the colored output goes, is a terminal
+	isStderrTerminal bool
+}
+
+func newHighlighting(cmd *cobra.Command) *highlighting {
+	cfg, err := config.Load(flags.ConfigFile)
+	if err != nil {
+		utils.HandleError(err)
+	}
+	themeName := cfg.Theme
+	if flags.Theme != "" {
+		themeName = flags.Theme
+	}
+	if themeName == "" {
+		themeName = theme.DefaultTheme.Name
+	}
+	themesDir, _ := config.ThemesDir()
+	th, err := theme.Load(themeName, themesDir)
+	if err != nil {
+		utils.HandleError(err)
+	}
+
+	colorDepth, err := style.ParseColorDepth(flags.ColorDepth)
+	if err != nil {
+		utils.HandleError(err)
+	}
+	th.SetColorDepth(colorDepth)
+
+	err = applyPatternStyles(patternsFromArgs, flags.PatternStyles)
+	if err != nil {
+		utils.HandleError(err)
+	}
+	userSyntax, userHighlights := userPatternSyntax(patternsFromArgs, th.Palette("UserPattern"))
+	for i := range userSyntax {
+		userSyntax[i].IgnoreCase = flags.IgnoreCase
+		userSyntax[i].WholeWord = flags.WholeWord
+	}
+	cfg.UserSyntax = append(cfg.UserSyntax, userSyntax...)
+	cfg.Highlight = append(cfg.Highlight, userHighlights...)
+	cfg.Rainbow = append(cfg.Rainbow, flags.Rainbow...)
+	if flags.Logfmt {
+		cfg.Logfmt.Enabled = true
+	}
+	// the pattern arguments have their own groups but are still user patterns
+	if slices.Contains(cfg.Rainbow, "UserPattern") {
+		for _, syn := range userSyntax {
+			cfg.Rainbow = append(cfg.Rainbow, syn.Group)
+		}
+	}
+
+	hl := &highlighting{theme: th}
+
+	if flags.Filter || flags.InvertMatch || flags.Context > 0 || flags.AfterContext > 0 || flags.BeforeContext > 0 {
+		if len(cfg.UserSyntax) == 0 {
+			utils.HandleError(fmt.Errorf("filtering requires at least one pattern"))
+		}
+		if flags.Context < 0 || flags.AfterContext < 0 || flags.BeforeContext < 0 {
+			utils.HandleError(fmt.Errorf("context line counts must not be negative"))
+		}
+		hl.filter = &reader.Filter{
+			Invert: flags.InvertMatch,
+			Before: flags.Context,
+			After:  flags.Context,
+		}
+		if cmd.Flags().Changed("before-context") {
+			hl.filter.Before = flags.BeforeContext
+		}
+		if cmd.Flags().Changed("after-context") {
+			hl.filter.After = flags.AfterContext
+		}
+	}
+
+	if flags.Level != "" {
+		threshold, err := level.Parse(flags.Level)
+		if err != nil {
+			utils.HandleError(err)
+		}
+		unknownPolicy, err := level.ParseUnknownPolicy(flags.LevelUnknown)
+		if err != nil {
+			utils.HandleError(err)
+		}
+		hl.levelFilter = &level.Filter{
+			Classifier: level.NewClassifier(config.DefaultConfig.BuiltInSyntax),
+			Threshold:  threshold,
+			Unknown:    unknownPolicy,
+		}
+	}
+
+	hl.renderer, err = renderer.New(cfg, th)
+	if err != nil {
+		utils.HandleError(err)
+	}
+
+	hl.isStderrTerminal = term.IsTerminal(int(os.Stderr.Fd()))
+
+	colorMode := style.ColorModeFromEnv()
+	if cmd.Flags().Changed("color") {
+		colorMode, err = style.ParseColorMode(flags.Color)
+		if err != nil {
+			utils.HandleError(err)
+		}
+	}
+	hl.renderer.NoColor = !colorMode.Enabled(hl.isStderrTerminal)
+
+	if flags.Pretty {
+		template := cfg.Pretty.Template
+		if cmd.Flags().Changed("pretty-template") {
+			template = flags.PrettyTemplate
+		}
+		hl.formatter = pretty.New(template, cfg.Pretty.TimeFormat)
+	}
+
+	return hl
+}
+
+// newLineBuffer returns a LineBuffer for one source, with the filters and
+// formatter set up and prefix before each of its lines.
+func (hl *highlighting) newLineBuffer(prefix string) *reader.LineBuffer {
+	lb := reader.NewLineBuffer(hl.renderer)
+	if hl.filter != nil {
+		f := *hl.filter
+		f.Match = hl.renderer.MatchesUserSyntax
+		lb.SetFilter(&f)
+	}
+	if hl.levelFilter != nil {
+		f := *hl.levelFilter
+		lb.SetLevelFilter(&f)
+	}
+	if hl.formatter != nil {
+		lb.SetFormatter(hl.formatter.Format)
+	}
+	lb.SetPrefix(prefix)
+	return lb
+}
+
+type source struct {
+	name   string
+	reader io.Reader
+	lb     *reader.LineBuffer
+	// whether reader is a file read in batches rather than a stream
+	batched bool
+}
+
+// readError reports an error reading src, such as a corrupt compressed file.
+func (src *source) readError(err error) error {
+	return fmt.Errorf("loglit: %s: %w", src.name, err)
+}
+
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
@@ -190,8 +398,33 @@ func Execute() {
 }
 
 func init() {
-	rootCmd.Flags().StringVarP(&flags.InputFile, "input", "i", "", "Input file to read logs from, if not provided, reads from stdin")
-	rootCmd.Flags().StringVarP(&flags.OutputFile, "output", "o", "", "Output file to write processed logs to")
-	rootCmd.Flags().BoolVarP(&flags.AppendMode, "append", "a", false, "Append to the output file instead of overwriting")
+	rootCmd.Flags().StringArrayVarP(&flags.InputFiles, "input", "i", nil, "Input file to read logs from, can be repeated and may be a glob or labeled as name=path, if not provided, reads from stdin")
+	rootCmd.Flags().StringVar(&flags.PrefixFormat, "prefix-format", "[{name}] ", "Prefix for lines from multiple inputs, {name} is replaced by the input label or file name")
+	rootCmd.Flags().BoolVar(&flags.MergeByTime, "merge-by-time", false, "Interleave lines of multiple inputs in chronological order of their leading timestamps")
+	rootCmd.Flags().IntVarP(&flags.Jobs, "jobs", "j", runtime.GOMAXPROCS(0), "Number of workers rendering the lines of input files in parallel, stdin and --follow are rendered line by line")
+	rootCmd.Flags().BoolVarP(&flags.Follow, "follow", "F", false, "Keep reading the input file as it grows, surviving rotation and truncation like tail -F")
+	rootCmd.Flags().IntVarP(&flags.Lines, "lines", "n", -1, "With --follow, start at the last NUM lines of the input file instead of its beginning")
+	rootCmd.PersistentFlags().StringVarP(&flags.OutputFile, "output", "o", "", "Output file to write processed logs to")
+	rootCmd.PersistentFlags().BoolVarP(&flags.AppendMode, "append", "a", false, "Append to the output file instead of overwriting")
+	rootCmd.PersistentFlags().StringVarP(&flags.ConfigFile, "config", "c", "", "Config file to load, defaults to $"+config.ConfigPathEnv+" or $XDG_CONFIG_HOME/loglit/config.toml")
+	rootCmd.PersistentFlags().StringVarP(&flags.Theme, "theme", "t", "", "Theme to use, either bundled ("+strings.Join(theme.Names(), ", ")+") or a file in $XDG_CONFIG_HOME/loglit/themes")
+	rootCmd.PersistentFlags().StringVar(&flags.ColorDepth, "color-depth", "auto", "Color depth of the terminal: auto, truecolor, 256, 16 or none")
+	rootCmd.PersistentFlags().StringVar(&flags.Color, "color", "auto", "When to color output: auto, always or never, overrides NO_COLOR and FORCE_COLOR")
+	rootCmd.PersistentFlags().BoolVar(&flags.FixedStrings, "fixed-strings", false, "Match the pattern arguments as plain strings instead of regexes")
+	rootCmd.PersistentFlags().StringVar(&flags.PatternsFile, "patterns-file", "", "Read additional patterns from a file, one per line")
+	rootCmd.PersistentFlags().BoolVar(&flags.IgnoreCase, "ignore-case", false, "Match the pattern arguments regardless of case")
+	rootCmd.PersistentFlags().BoolVarP(&flags.WholeWord, "whole-word", "w", false, "Only match the pattern arguments as whole words")
+	rootCmd.PersistentFlags().StringArrayVar(&flags.PatternStyles, "pattern-style", nil, "Style of the pattern argument at the same position, e.g. red,bold or #ffffff,bg=blue,underline, can be repeated")
+	rootCmd.PersistentFlags().BoolVar(&flags.Logfmt, "logfmt", false, "Highlight key=value pairs of lines that are not JSON, like [logfmt] enabled = true in the config file")
+	rootCmd.PersistentFlags().StringSliceVar(&flags.Rainbow, "rainbow", nil, "Color matches of these highlight groups by their text, so equal IDs share a color, e.g. LogUUID,UserPattern")
+	rootCmd.PersistentFlags().BoolVarP(&flags.Filter, "filter", "f", false, "Only output lines matching the provided patterns")
+	rootCmd.PersistentFlags().BoolVarP(&flags.InvertMatch, "invert-match", "v", false, "Only output lines not matching the provided patterns, implies --filter")
+	rootCmd.PersistentFlags().IntVarP(&flags.AfterContext, "after-context", "A", 0, "Output NUM lines after each matching line, implies --filter")
+	rootCmd.PersistentFlags().IntVarP(&flags.BeforeContext, "before-context", "B", 0, "Output NUM lines before each matching line, implies --filter")
+	rootCmd.PersistentFlags().IntVarP(&flags.Context, "context", "C", 0, "Output NUM lines before and after each matching line, implies --filter")
+	rootCmd.PersistentFlags().StringVarP(&flags.Level, "level", "l", "", "Only output lines with at least this log level: trace, debug, info, notice, warn, error or fatal")
+	rootCmd.PersistentFlags().StringVar(&flags.LevelUnknown, "level-unknown", "inherit", "How --level treats lines without a log level: inherit from the previous line, show or hide")
+	rootCmd.PersistentFlags().BoolVar(&flags.Pretty, "pretty", false, "Reformat JSON log lines into compact text, the output file still receives the original lines")
+	rootCmd.PersistentFlags().StringVar(&flags.PrettyTemplate, "pretty-template", pretty.DefaultTemplate, "Fields shown first by --pretty, other fields are appended as key=value")
 	rootCmd.Flags().StringVar(&flags.Profile, "profile", "", "Enable profiling, write CPU profile data to the specified file")
 }
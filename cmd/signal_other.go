@@ -0,0 +1,23 @@
+//go:build !unix
+
+package cmd
+
+import (
+	"os"
+	"os/signal"
+)
+
+// forwardSignals keeps loglit running on an interrupt until the returned
+// function is called, the console already sends it to p as well.
+func forwardSignals(p *os.Process, group bool) func() {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt)
+	return func() {
+		signal.Stop(c)
+	}
+}
+
+// exitStatus returns the exit code of a process.
+func exitStatus(state *os.ProcessState) int {
+	return state.ExitCode()
+}
@@ -0,0 +1,75 @@
+//go:build unix
+
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+var forwardedSignals = []os.Signal{
+	syscall.SIGINT,
+	syscall.SIGQUIT,
+	syscall.SIGTERM,
+	syscall.SIGHUP,
+	syscall.SIGUSR1,
+	syscall.SIGUSR2,
+}
+
+// forwardSignals passes the signals loglit receives on to p until the
+// returned function is called. Signals typed at the terminal are already sent
+// to the whole foreground process group, including p, and are not sent twice.
+// With group, p leads a process group in its own session, which the terminal
+// does not reach, and the group gets every signal.
+func forwardSignals(p *os.Process, group bool) func() {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, forwardedSignals...)
+	go func() {
+		for sig := range c {
+			if group {
+				_ = unix.Kill(-p.Pid, sig.(syscall.Signal))
+				continue
+			}
+			if isTerminalSignal(sig) && inForeground() {
+				continue
+			}
+			_ = p.Signal(sig)
+		}
+	}()
+	return func() {
+		signal.Stop(c)
+		close(c)
+	}
+}
+
+func isTerminalSignal(sig os.Signal) bool {
+	return sig == syscall.SIGINT || sig == syscall.SIGQUIT
+}
+
+// inForeground reports whether loglit is in the foreground process group of
+// its controlling terminal.
+func inForeground() bool {
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return false
+	}
+	defer tty.Close()
+	pgrp, err := tcgetpgrp(int(tty.Fd()))
+	if err != nil {
+		return false
+	}
+	own, err := unix.Getpgid(0)
+	return err == nil && pgrp == own
+}
+
+// exitStatus returns the exit status of a process like a shell would, 128
+// plus the signal number if it was killed by a signal.
+func exitStatus(state *os.ProcessState) int {
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return state.ExitCode()
+}
@@ -0,0 +1,21 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package cmd
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// tcgetpgrp returns the foreground process group of the terminal fd, like
+// tcgetpgrp(3). x/sys has no wrapper for it and TIOCGPGRP writes a 32 bit
+// pid_t, which IoctlGetInt would read as part of a wider int.
+func tcgetpgrp(fd int) (int, error) {
+	var pgrp int32
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), uintptr(unix.TIOCGPGRP), uintptr(unsafe.Pointer(&pgrp)))
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(pgrp), nil
+}
@@ -0,0 +1,11 @@
+//go:build unix && !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package cmd
+
+import "errors"
+
+// tcgetpgrp is not supported on this system, so loglit is never known to be
+// in the foreground and passes on every signal.
+func tcgetpgrp(fd int) (int, error) {
+	return 0, errors.ErrUnsupported
+}
@@ -0,0 +1,234 @@
+// Package highlight colors log lines with the built-in patterns and themes of
+// loglit, for use in other programs.
+//
+//	h, err := highlight.New(highlight.WithTheme("light"), highlight.WithPatterns(`ERR-\d+`))
+//	if err != nil {
+//		return err
+//	}
+//	w := highlight.NewWriter(os.Stderr, h)
+//	defer w.Flush()
+//	log.SetOutput(w)
+package highlight
+
+import (
+	"io"
+	"regexp"
+	"slices"
+
+	"github.com/madmaxieee/loglit/internal/config"
+	"github.com/madmaxieee/loglit/internal/proto"
+	"github.com/madmaxieee/loglit/internal/renderer"
+	"github.com/madmaxieee/loglit/internal/style"
+	"github.com/madmaxieee/loglit/internal/theme"
+)
+
+// Highlighter colors log lines. Implementations are safe for concurrent use.
+type Highlighter interface {
+	// Highlight returns line with the escape sequences of its colors.
+	Highlight(line string) string
+	// HighlightTo writes line with the escape sequences of its colors to w.
+	// line is neither modified nor retained.
+	HighlightTo(w io.Writer, line []byte) error
+}
+
+// ColorDepth is the number of colors the output can display.
+type ColorDepth int
+
+const (
+	// ColorDepthAuto detects the color depth from the COLORTERM and TERM
+	// environment variables.
+	ColorDepthAuto ColorDepth = iota
+	ColorDepthTrueColor
+	ColorDepth256
+	ColorDepth16
+	// ColorDepthNone only styles text as bold, italic or underlined.
+	ColorDepthNone
+)
+
+func (d ColorDepth) style() style.ColorDepth {
+	switch d {
+	case ColorDepthTrueColor:
+		return style.ColorDepthTrueColor
+	case ColorDepth256:
+		return style.ColorDepth256
+	case ColorDepth16:
+		return style.ColorDepth16
+	case ColorDepthNone:
+		return style.ColorDepthNone
+	}
+	return style.DetectColorDepth()
+}
+
+type options struct {
+	theme      string
+	configFile string
+	loadConfig bool
+	themesDir  string
+	colorDepth ColorDepth
+	noColor    bool
+	patterns   []string
+	literals   []string
+	rainbow    []string
+}
+
+// Option configures a Highlighter created by New.
+type Option func(*options)
+
+// WithTheme selects a theme by name, either a bundled one or a file in the
+// themes directory, see WithThemesDir. It overrides the theme of the config
+// file.
+func WithTheme(name string) Option {
+	return func(o *options) {
+		o.theme = name
+	}
+}
+
+// WithConfigFile loads a loglit config file. An empty path loads the file
+// the loglit command would, from $LOGLIT_CONFIG or
+// $XDG_CONFIG_HOME/loglit/config.toml. Without this option, no config file is
+// read and the built-in defaults are used.
+func WithConfigFile(path string) Option {
+	return func(o *options) {
+		o.configFile = path
+		o.loadConfig = true
+	}
+}
+
+// WithThemesDir looks up theme files in dir before the bundled themes.
+// Without it, the themes directory of the loglit command,
+// $XDG_CONFIG_HOME/loglit/themes, is only used along with WithConfigFile, so
+// that the output does not depend on the files of the host otherwise.
+func WithThemesDir(dir string) Option {
+	return func(o *options) {
+		o.themesDir = dir
+	}
+}
+
+// WithColorDepth sets the color depth of the output, colors are degraded to
+// the nearest color the depth supports. It defaults to ColorDepthAuto.
+func WithColorDepth(depth ColorDepth) Option {
+	return func(o *options) {
+		o.colorDepth = depth
+	}
+}
+
+// WithColor enables or disables all escape sequences. Disabled, lines are
+// returned unchanged, such as when the output is not a terminal. Colors are
+// enabled by default.
+func WithColor(enabled bool) Option {
+	return func(o *options) {
+		o.noColor = !enabled
+	}
+}
+
+// WithPatterns highlights matches of the regular expressions, like the
+// pattern arguments of the loglit command. Each pattern gets its own color.
+func WithPatterns(patterns ...string) Option {
+	return func(o *options) {
+		o.patterns = append(o.patterns, patterns...)
+	}
+}
+
+// WithLiterals highlights occurrences of plain strings, like patterns given
+// with --fixed-strings.
+func WithLiterals(literals ...string) Option {
+	return func(o *options) {
+		o.literals = append(o.literals, literals...)
+	}
+}
+
+// WithRainbow colors matches of the highlight groups by their text, so equal
+// IDs share a color, like --rainbow.
+func WithRainbow(groups ...string) Option {
+	return func(o *options) {
+		o.rainbow = append(o.rainbow, groups...)
+	}
+}
+
+type highlighter struct {
+	renderer *renderer.Renderer
+}
+
+// New creates a Highlighter with the built-in syntax and the default theme,
+// changed by opts.
+func New(opts ...Option) (Highlighter, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	cfg := config.GetDefaultConfig()
+	if o.loadConfig {
+		var err error
+		cfg, err = config.Load(o.configFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	themeName := cfg.Theme
+	if o.theme != "" {
+		themeName = o.theme
+	}
+	if themeName == "" {
+		themeName = theme.DefaultTheme.Name
+	}
+	themesDir := o.themesDir
+	if themesDir == "" && o.loadConfig {
+		themesDir, _ = config.ThemesDir()
+	}
+	th, err := theme.Load(themeName, themesDir)
+	if err != nil {
+		return nil, err
+	}
+	th.SetColorDepth(o.colorDepth.style())
+
+	var userSyntax []proto.Syntax
+	palette := th.Palette("UserPattern")
+	for i, pattern := range o.patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		group := "UserPattern"
+		if len(palette) > 0 {
+			group = palette[i%len(palette)].Group
+		}
+		userSyntax = append(userSyntax, proto.Syntax{Group: group, Pattern: proto.Pattern{Regexp: re}})
+	}
+	if len(o.literals) > 0 {
+		userSyntax = append(userSyntax, proto.Syntax{Group: "UserPattern", Literals: o.literals})
+	}
+	// copy instead of appending, the slices may be shared with the defaults
+	cfg.UserSyntax = slices.Concat(cfg.UserSyntax, userSyntax)
+	cfg.Rainbow = slices.Concat(cfg.Rainbow, o.rainbow)
+	// the patterns have their own groups but are still user patterns
+	if slices.Contains(cfg.Rainbow, "UserPattern") {
+		for _, syn := range userSyntax {
+			cfg.Rainbow = append(cfg.Rainbow, syn.Group)
+		}
+	}
+
+	r, err := renderer.New(cfg, th)
+	if err != nil {
+		return nil, err
+	}
+	r.NoColor = o.noColor
+	return &highlighter{renderer: r}, nil
+}
+
+func (h *highlighter) Highlight(line string) string {
+	// errors only occur for missing highlight groups, which New rules out,
+	// and leave the line unchanged
+	highlighted, _ := h.renderer.Render(line)
+	return highlighted
+}
+
+func (h *highlighter) HighlightTo(w io.Writer, line []byte) error {
+	return h.renderer.RenderTo(w, line)
+}
+
+// Themes returns the names of the bundled themes.
+func Themes() []string {
+	return theme.Names()
+}
@@ -0,0 +1,123 @@
+package highlight
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHighlighter(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	h, err := New(WithColorDepth(ColorDepthTrueColor), WithPatterns(`ERR-\d+`), WithLiterals("[main]"))
+	if err != nil {
+		t.Fatalf("failed to create highlighter: %v", err)
+	}
+
+	line := "2024-05-01 12:00:00 ERROR [main] ERR-42 failed"
+	out := h.Highlight(line)
+	if out == line || !strings.Contains(out, "\033[") {
+		t.Errorf("expected %q to be highlighted, got %q", line, out)
+	}
+	for _, part := range []string{"ERR-42", "[main]", "2024-05-01"} {
+		if !strings.Contains(out, part+"\033[") {
+			t.Errorf("expected %q to be highlighted in %q", part, out)
+		}
+	}
+
+	var b bytes.Buffer
+	if err := h.HighlightTo(&b, []byte(line)); err != nil {
+		t.Fatalf("HighlightTo failed: %v", err)
+	}
+	if b.String() != out {
+		t.Errorf("HighlightTo wrote %q, Highlight returned %q", b.String(), out)
+	}
+}
+
+func TestHighlighterOptions(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	line := "2024-05-01 12:00:00 ERROR failed"
+
+	h, err := New(WithColor(false))
+	if err != nil {
+		t.Fatalf("failed to create highlighter: %v", err)
+	}
+	if out := h.Highlight(line); out != line {
+		t.Errorf("expected the line unchanged without color, got %q", out)
+	}
+
+	h, err = New(WithTheme("high-contrast"), WithColorDepth(ColorDepth16))
+	if err != nil {
+		t.Fatalf("failed to create highlighter: %v", err)
+	}
+	if out := h.Highlight(line); strings.Contains(out, "38;2;") {
+		t.Errorf("expected only 16 colors, got %q", out)
+	}
+
+	if _, err := New(WithPatterns("(")); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+	if _, err := New(WithTheme("no-such-theme")); err == nil {
+		t.Error("expected an error for an unknown theme")
+	}
+}
+
+func TestHighlighterThemesDir(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	themesDir := filepath.Join(configHome, "loglit", "themes")
+	if err := os.MkdirAll(themesDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	themeFile := "[highlight.ErrorMsg]\nfg = \"#123456\"\n"
+	if err := os.WriteFile(filepath.Join(themesDir, "default.toml"), []byte(themeFile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	const custom = "38;2;18;52;86"
+
+	h, err := New(WithColorDepth(ColorDepthTrueColor))
+	if err != nil {
+		t.Fatalf("failed to create highlighter: %v", err)
+	}
+	if out := h.Highlight("ERROR"); strings.Contains(out, custom) {
+		t.Errorf("expected the themes of the host to be ignored, got %q", out)
+	}
+
+	h, err = New(WithColorDepth(ColorDepthTrueColor), WithThemesDir(themesDir))
+	if err != nil {
+		t.Fatalf("failed to create highlighter: %v", err)
+	}
+	if out := h.Highlight("ERROR"); !strings.Contains(out, custom) {
+		t.Errorf("expected the theme file to be used, got %q", out)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	h, err := New(WithColorDepth(ColorDepthTrueColor))
+	if err != nil {
+		t.Fatalf("failed to create highlighter: %v", err)
+	}
+
+	var b bytes.Buffer
+	w := NewWriter(&b, h)
+	for _, chunk := range []string{"INFO st", "arted\nWARN slow\r\nERR", "OR failed"} {
+		n, err := w.Write([]byte(chunk))
+		if err != nil || n != len(chunk) {
+			t.Fatalf("Write(%q) = %d, %v", chunk, n, err)
+		}
+	}
+	expected := h.Highlight("INFO started") + "\n" + h.Highlight("WARN slow") + "\r\n"
+	if b.String() != expected {
+		t.Errorf("expected %q before Flush, got %q", expected, b.String())
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	expected += h.Highlight("ERROR failed")
+	if b.String() != expected {
+		t.Errorf("expected %q after Flush, got %q", expected, b.String())
+	}
+}
@@ -0,0 +1,23 @@
+package highlight
+
+import (
+	"io"
+	"log/slog"
+)
+
+// NewHandler returns a slog.Handler that formats records like
+// slog.TextHandler and highlights them with h, the same way loglit highlights
+// text logs piped into it: levels get their LogLv* colors and attributes are
+// highlighted as logfmt keys and values, along with the built-in syntax.
+// Attributes are highlighted even though logfmt is off unless a config file
+// enables it, except with Highlighters of other packages, which are used as
+// they are.
+func NewHandler(w io.Writer, h Highlighter, opts *slog.HandlerOptions) slog.Handler {
+	if hl, ok := h.(*highlighter); ok && !hl.renderer.Config.Logfmt.Enabled {
+		r := *hl.renderer
+		r.Config.Logfmt.Enabled = true
+		h = &highlighter{renderer: &r}
+	}
+	// the text handler writes each record as a single line in one call
+	return slog.NewTextHandler(NewWriter(w, h), opts)
+}
@@ -0,0 +1,89 @@
+package highlight
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newLogfmtHighlighter creates a Highlighter with a config file that turns
+// logfmt on or off.
+func newLogfmtHighlighter(t *testing.T, enabled bool) Highlighter {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	content := fmt.Sprintf("[logfmt]\nenabled = %t\n", enabled)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	h, err := New(WithConfigFile(path), WithColorDepth(ColorDepthTrueColor))
+	if err != nil {
+		t.Fatalf("failed to create highlighter: %v", err)
+	}
+	return h
+}
+
+func TestHandler(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	h := newLogfmtHighlighter(t, true)
+	opts := &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		// drop the time so the output is deterministic
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}
+
+	log := func(handler slog.Handler) {
+		logger := slog.New(handler).With("service", "api").WithGroup("req")
+		logger.Warn("slow request", "id", "123e4567-e89b-12d3-a456-426614174000", "took", "1.5s")
+		logger.Debug("done", "status", 200)
+	}
+
+	var plain, colored bytes.Buffer
+	log(slog.NewTextHandler(&plain, opts))
+	log(NewHandler(&colored, h, opts))
+
+	var expected strings.Builder
+	for _, line := range strings.SplitAfter(plain.String(), "\n") {
+		content := strings.TrimSuffix(line, "\n")
+		if content != "" {
+			expected.WriteString(h.Highlight(content) + "\n")
+		}
+	}
+	if colored.String() != expected.String() {
+		t.Errorf("expected the text handler output highlighted:\n%q\ngot\n%q", expected.String(), colored.String())
+	}
+
+	// the level value is styled like the level keyword on its own
+	if warn := h.Highlight("WARN"); warn == "WARN" || !strings.Contains(colored.String(), warn) {
+		t.Errorf("expected the level to be highlighted as %q in %q", warn, colored.String())
+	}
+}
+
+func TestHandlerLogfmtDisabled(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	opts := &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}
+
+	var plain, colored bytes.Buffer
+	slog.New(slog.NewTextHandler(&plain, opts)).Info("started", "user", "alice")
+	slog.New(NewHandler(&colored, newLogfmtHighlighter(t, false), opts)).Info("started", "user", "alice")
+
+	expected := newLogfmtHighlighter(t, true).Highlight(strings.TrimSuffix(plain.String(), "\n")) + "\n"
+	if colored.String() != expected {
+		t.Errorf("expected the attributes highlighted as logfmt:\n%q\ngot\n%q", expected, colored.String())
+	}
+}
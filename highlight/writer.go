@@ -0,0 +1,80 @@
+package highlight
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// Writer is an io.Writer that highlights the lines written to it and passes
+// them on to another writer. A partial line is kept until its newline is
+// written or Flush is called. It is safe for concurrent use.
+type Writer struct {
+	mu sync.Mutex
+	w  io.Writer
+	h  Highlighter
+	// partial line waiting for its newline
+	pending []byte
+	out     bytes.Buffer
+}
+
+// NewWriter returns a Writer that highlights lines with h and writes them to
+// w, with a single write to w per call to Write.
+func NewWriter(w io.Writer, h Highlighter) *Writer {
+	return &Writer{w: w, h: h}
+}
+
+// Write highlights the complete lines of p, along with any partial line
+// written before, and writes them to the underlying writer. p is always
+// consumed entirely, an error means the lines could not be written.
+func (hw *Writer) Write(p []byte) (int, error) {
+	hw.mu.Lock()
+	defer hw.mu.Unlock()
+
+	hw.pending = append(hw.pending, p...)
+	hw.out.Reset()
+	rest := hw.pending
+	for {
+		idx := bytes.IndexByte(rest, '\n')
+		if idx == -1 {
+			break
+		}
+		hw.highlightLine(rest[:idx+1])
+		rest = rest[idx+1:]
+	}
+	hw.pending = append(hw.pending[:0], rest...)
+
+	return len(p), hw.writeOut()
+}
+
+// Flush highlights and writes the partial line, if any.
+func (hw *Writer) Flush() error {
+	hw.mu.Lock()
+	defer hw.mu.Unlock()
+
+	hw.out.Reset()
+	hw.highlightLine(hw.pending)
+	hw.pending = hw.pending[:0]
+	return hw.writeOut()
+}
+
+// highlightLine highlights line into hw.out, keeping its line ending as is.
+func (hw *Writer) highlightLine(line []byte) {
+	if len(line) == 0 {
+		return
+	}
+	content := bytes.TrimSuffix(line, []byte("\n"))
+	content = bytes.TrimSuffix(content, []byte("\r"))
+	// writing to a bytes.Buffer cannot fail, and a line that cannot be
+	// highlighted is written unchanged
+	_ = hw.h.HighlightTo(&hw.out, content)
+	hw.out.Write(line[len(content):])
+}
+
+func (hw *Writer) writeOut() error {
+	if hw.out.Len() == 0 {
+		return nil
+	}
+	_, err := hw.w.Write(hw.out.Bytes())
+	return err
+}
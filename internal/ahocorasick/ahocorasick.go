@@ -0,0 +1,182 @@
+// Package ahocorasick finds many literal strings in a text in a single pass.
+package ahocorasick
+
+import (
+	"cmp"
+	"slices"
+)
+
+type node struct {
+	next map[byte]int32
+	fail int32
+	// index of a pattern ending at this node, or -1
+	out int32
+	// nearest node on the fail chain that ends a pattern, or -1
+	dict  int32
+	depth int32
+}
+
+// Matcher is an Aho-Corasick automaton built from a list of patterns.
+type Matcher struct {
+	nodes   []node
+	lengths []int
+	// same[i] is the index of another pattern equal to pattern i, or -1, so
+	// that duplicate patterns form a list starting at the node's out
+	same     []int32
+	foldCase bool
+}
+
+// Match is an occurrence of the pattern with the given index at
+// text[Start:End].
+type Match struct {
+	Pattern int
+	Start   int
+	End     int
+}
+
+// Buffer holds the memory FindAll reuses between calls. The zero value is
+// ready to use.
+type Buffer struct {
+	candidates []Match
+	matches    []Match
+}
+
+// New builds a matcher for patterns. With foldCase, ASCII letters match
+// regardless of case. Empty patterns never match.
+func New(patterns []string, foldCase bool) *Matcher {
+	m := &Matcher{
+		nodes:    []node{{out: -1, dict: -1}},
+		lengths:  make([]int, len(patterns)),
+		same:     make([]int32, len(patterns)),
+		foldCase: foldCase,
+	}
+	for i, pattern := range patterns {
+		m.lengths[i] = len(pattern)
+		m.same[i] = -1
+		if pattern == "" {
+			continue
+		}
+		cur := int32(0)
+		for j := 0; j < len(pattern); j++ {
+			c := m.fold(pattern[j])
+			next, ok := m.nodes[cur].next[c]
+			if !ok {
+				next = int32(len(m.nodes))
+				m.nodes = append(m.nodes, node{out: -1, dict: -1, depth: m.nodes[cur].depth + 1})
+				if m.nodes[cur].next == nil {
+					m.nodes[cur].next = make(map[byte]int32)
+				}
+				m.nodes[cur].next[c] = next
+			}
+			cur = next
+		}
+		m.same[i] = m.nodes[cur].out
+		m.nodes[cur].out = int32(i)
+	}
+	m.buildLinks()
+	return m
+}
+
+// buildLinks computes the fail and dictionary links breadth first.
+func (m *Matcher) buildLinks() {
+	queue := make([]int32, 0, len(m.nodes))
+	for _, child := range m.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for c, child := range m.nodes[cur].next {
+			fail := m.nodes[cur].fail
+			for {
+				if next, ok := m.nodes[fail].next[c]; ok {
+					m.nodes[child].fail = next
+					break
+				}
+				if fail == 0 {
+					m.nodes[child].fail = 0
+					break
+				}
+				fail = m.nodes[fail].fail
+			}
+			f := m.nodes[child].fail
+			if m.nodes[f].out >= 0 {
+				m.nodes[child].dict = f
+			} else {
+				m.nodes[child].dict = m.nodes[f].dict
+			}
+			queue = append(queue, child)
+		}
+	}
+}
+
+func (m *Matcher) fold(c byte) byte {
+	if m.foldCase && c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+// Len returns the number of patterns of the matcher.
+func (m *Matcher) Len() int {
+	return len(m.lengths)
+}
+
+// FindAll returns the leftmost-longest non-overlapping matches in text,
+// ordered by position. If accept is not nil, matches it rejects are skipped
+// in favor of shorter matches at the same position. Of duplicate patterns,
+// the first one accept allows is matched. The result is backed by buf and
+// stays valid until its next use.
+func (m *Matcher) FindAll(buf *Buffer, text string, accept func(Match) bool) []Match {
+	if len(m.nodes) == 1 {
+		return nil
+	}
+
+	candidates := buf.candidates[:0]
+	cur := int32(0)
+	for i := 0; i < len(text); i++ {
+		c := m.fold(text[i])
+		for {
+			if next, ok := m.nodes[cur].next[c]; ok {
+				cur = next
+				break
+			}
+			if cur == 0 {
+				break
+			}
+			cur = m.nodes[cur].fail
+		}
+		for n := cur; n > 0; n = m.nodes[n].dict {
+			for p := m.nodes[n].out; p >= 0; p = m.same[p] {
+				start := i + 1 - int(m.nodes[n].depth)
+				candidates = append(candidates, Match{Pattern: int(p), Start: start, End: i + 1})
+			}
+		}
+	}
+	buf.candidates = candidates
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	// leftmost first, then longest first, then in the order of the patterns
+	slices.SortFunc(candidates, func(a, b Match) int {
+		if c := cmp.Compare(a.Start, b.Start); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.End, a.End); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Pattern, b.Pattern)
+	})
+	matches := buf.matches[:0]
+	end := 0
+	for _, match := range candidates {
+		if match.Start < end || (accept != nil && !accept(match)) {
+			continue
+		}
+		matches = append(matches, match)
+		end = match.End
+	}
+	buf.matches = matches
+	return matches
+}
@@ -0,0 +1,105 @@
+package ahocorasick
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindAll(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		foldCase bool
+		text     string
+		expected []Match
+	}{
+		{
+			name:     "leftmost longest",
+			patterns: []string{"he", "she", "hers", "his"},
+			text:     "ushers his",
+			expected: []Match{{Pattern: 1, Start: 1, End: 4}, {Pattern: 3, Start: 7, End: 10}},
+		},
+		{
+			name:     "metacharacters",
+			patterns: []string{"[main]", "10.0.0.1", "a+b"},
+			text:     "[main] connect 10.0.0.1 a+b aab",
+			expected: []Match{{Pattern: 0, Start: 0, End: 6}, {Pattern: 1, Start: 15, End: 23}, {Pattern: 2, Start: 24, End: 27}},
+		},
+		{
+			name:     "nested suffix",
+			patterns: []string{"abcd", "bc"},
+			text:     "abcx",
+			expected: []Match{{Pattern: 1, Start: 1, End: 3}},
+		},
+		{
+			name:     "fold case",
+			patterns: []string{"Warn"},
+			foldCase: true,
+			text:     "WARN warn",
+			expected: []Match{{Pattern: 0, Start: 0, End: 4}, {Pattern: 0, Start: 5, End: 9}},
+		},
+		{
+			name:     "duplicates",
+			patterns: []string{"warn", "Warn", "warn"},
+			foldCase: true,
+			text:     "WARN",
+			expected: []Match{{Pattern: 0, Start: 0, End: 4}},
+		},
+		{
+			name:     "no match",
+			patterns: []string{"x", ""},
+			text:     "abc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(tt.patterns, tt.foldCase)
+			got := m.FindAll(new(Buffer), tt.text, nil)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("FindAll(%q) = %+v, want %+v", tt.text, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFindAllAccept(t *testing.T) {
+	m := New([]string{"id-1", "id-12"}, false)
+	got := m.FindAll(new(Buffer), "id-123", func(match Match) bool { return match.Pattern == 0 })
+	expected := []Match{{Pattern: 0, Start: 0, End: 4}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+
+	// a duplicate pattern is matched when the first one is rejected
+	m = New([]string{"id", "x", "id"}, false)
+	got = m.FindAll(new(Buffer), "id", func(match Match) bool { return match.Pattern != 0 })
+	expected = []Match{{Pattern: 2, Start: 0, End: 2}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestFindAllReusesBuffer(t *testing.T) {
+	m := New([]string{"he", "she", "hers", "his"}, false)
+	var buf Buffer
+	allocs := testing.AllocsPerRun(100, func() { m.FindAll(&buf, "ushers his hers", nil) })
+	if allocs != 0 {
+		t.Errorf("expected no allocations with a reused buffer, got %v", allocs)
+	}
+}
+
+func BenchmarkFindAll(b *testing.B) {
+	var patterns []string
+	for i := range 1000 {
+		patterns = append(patterns, "customer-"+strings.Repeat("x", i%7)+string(rune('a'+i%26))+string(rune('a'+i/26%26)))
+	}
+	m := New(patterns, false)
+	line := "2023-10-27 10:00:00 INFO request from customer-xxab handled for customer-q in 10ms"
+	var buf Buffer
+	b.ReportAllocs()
+	for b.Loop() {
+		m.FindAll(&buf, line, nil)
+	}
+}
@@ -12,10 +12,33 @@ type syntax = proto.Syntax
 type highlight = style.Highlight
 
 type Config struct {
+	Theme              string
 	BuiltInSyntaxLower []syntax
 	BuiltInSyntax      []syntax
 	UserSyntax         []syntax
+	BuiltInHighlight   []highlight
 	Highlight          []highlight
+	Logfmt             LogfmtConfig
+	Pretty             PrettyConfig
+	// Rainbow lists the groups whose matches are colored from the theme's
+	// LogRainbow palette by hashing the matched text.
+	Rainbow []string
+}
+
+type LogfmtConfig struct {
+	// Enabled highlights key=value pairs in lines that are not JSON. It is
+	// off by default, as it changes the rendering of any text with an =.
+	Enabled bool
+	// KeyGroups maps keys to the highlight group of their values.
+	KeyGroups map[string]string
+}
+
+type PrettyConfig struct {
+	// Template selects the fields of a JSON record shown by --pretty, such as
+	// "{time} {level} {msg}". Empty means the default template.
+	Template string
+	// TimeFormat is the Go time layout used for the {time} field.
+	TimeFormat string
 }
 
 func cap(c byte) byte {
@@ -219,84 +242,116 @@ var DefaultConfig = Config{
 		// 	Pattern: proto.MustCompile(`(?:\.{1,2}|~|[^\s/'"]+)?(?:/[^\s/'"]+)+/?`),
 		// },
 
-		// log levels
+		// log levels, in any case such as wArN, except for the single letter
+		// ones which are too common in lowercase
+		{
+			Group:      "LogLvFatal",
+			Keywords:   []string{"fatal"},
+			IgnoreCase: true,
+		},
+		{
+			Group:      "LogLvEmergency",
+			Keywords:   []string{"emerg", "emergency"},
+			IgnoreCase: true,
+		},
 		{
-			Group:    "LogLvFatal",
-			Keywords: lowerCapSCREAM("fatal"),
+			Group:      "LogLvAlert",
+			Keywords:   []string{"alert"},
+			IgnoreCase: true,
 		},
 		{
-			Group:    "LogLvEmergency",
-			Keywords: utils.JoinSlices(lowerCapSCREAM("emerg"), lowerCapSCREAM("emergency")),
+			Group:      "LogLvCritical",
+			Keywords:   []string{"crit", "critical"},
+			IgnoreCase: true,
 		},
 		{
-			Group:    "LogLvAlert",
-			Keywords: lowerCapSCREAM("alert"),
+			Group:      "LogLvError",
+			Keywords:   []string{"err", "error", "errors"},
+			IgnoreCase: true,
 		},
 		{
-			Group:    "LogLvCritical",
-			Keywords: utils.JoinSlices(lowerCapSCREAM("crit"), lowerCapSCREAM("critical")),
+			Group:    "LogLvError",
+			Keywords: []string{"E"},
 		},
 		{
-			Group: "LogLvError",
-			Keywords: utils.JoinSlices(
-				[]string{"E"},
-				lowerCapSCREAM("err"),
-				lowerCapSCREAM("error"),
-				lowerCapSCREAM("errors"),
-			),
+			Group:      "LogLvFail",
+			Keywords:   []string{"fail", "failed", "failure"},
+			IgnoreCase: true,
 		},
 		{
-			Group: "LogLvFail",
-			Keywords: utils.JoinSlices(
-				[]string{"F"},
-				lowerCapSCREAM("fail"),
-				lowerCapSCREAM("failed"),
-				lowerCapSCREAM("failure"),
-			),
+			Group:    "LogLvFail",
+			Keywords: []string{"F"},
 		},
 		{
-			Group:    "LogLvFault",
-			Keywords: lowerCapSCREAM("fault"),
+			Group:      "LogLvFault",
+			Keywords:   []string{"fault"},
+			IgnoreCase: true,
 		},
 		{
-			Group:    "LogLvNack",
-			Keywords: utils.JoinSlices(lowerCapSCREAM("nack"), lowerCapSCREAM("nak")),
+			Group:      "LogLvNack",
+			Keywords:   []string{"nack", "nak"},
+			IgnoreCase: true,
+		},
+		{
+			Group:      "LogLvWarning",
+			Keywords:   []string{"warn", "warning"},
+			IgnoreCase: true,
 		},
 		{
 			Group:    "LogLvWarning",
-			Keywords: utils.JoinSlices([]string{"W"}, lowerCapSCREAM("warn"), lowerCapSCREAM("warning")),
+			Keywords: []string{"W"},
+		},
+		{
+			Group:      "LogLvBad",
+			Keywords:   []string{"bad"},
+			IgnoreCase: true,
 		},
 		{
-			Group:    "LogLvBad",
-			Keywords: lowerCapSCREAM("bad"),
+			Group:      "LogLvNotice",
+			Keywords:   []string{"notice"},
+			IgnoreCase: true,
 		},
 		{
-			Group:    "LogLvNotice",
-			Keywords: lowerCapSCREAM("notice"),
+			Group:      "LogLvInfo",
+			Keywords:   []string{"info"},
+			IgnoreCase: true,
 		},
 		{
 			Group:    "LogLvInfo",
-			Keywords: utils.JoinSlices([]string{"I"}, lowerCapSCREAM("info")),
+			Keywords: []string{"I"},
+		},
+		{
+			Group:      "LogLvDebug",
+			Keywords:   []string{"dbg", "debug"},
+			IgnoreCase: true,
 		},
 		{
 			Group:    "LogLvDebug",
-			Keywords: utils.JoinSlices([]string{"D"}, lowerCapSCREAM("dbg"), lowerCapSCREAM("debug")),
+			Keywords: []string{"D"},
+		},
+		{
+			Group:      "LogLvTrace",
+			Keywords:   []string{"trace"},
+			IgnoreCase: true,
 		},
 		{
-			Group:    "LogLvTrace",
-			Keywords: lowerCapSCREAM("trace"),
+			Group:      "LogLvVerbose",
+			Keywords:   []string{"verbose"},
+			IgnoreCase: true,
 		},
 		{
 			Group:    "LogLvVerbose",
-			Keywords: utils.JoinSlices([]string{"V"}, lowerCapSCREAM("verbose")),
+			Keywords: []string{"V"},
 		},
 		{
-			Group:    "LogLvPass",
-			Keywords: utils.JoinSlices(lowerCapSCREAM("pass"), lowerCapSCREAM("passed")),
+			Group:      "LogLvPass",
+			Keywords:   []string{"pass", "passed"},
+			IgnoreCase: true,
 		},
 		{
-			Group:    "LogLvSuccess",
-			Keywords: utils.JoinSlices(lowerCapSCREAM("succeed"), lowerCapSCREAM("succeeded"), lowerCapSCREAM("success")),
+			Group:      "LogLvSuccess",
+			Keywords:   []string{"succeed", "succeeded", "success"},
+			IgnoreCase: true,
 		},
 
 		// Composite log levels e.g. *_INFO
@@ -346,7 +401,7 @@ var DefaultConfig = Config{
 		},
 	},
 
-	Highlight: []highlight{
+	BuiltInHighlight: []highlight{
 		{Group: "LogNumber", Link: strPtr("Number")},
 		{Group: "LogNumberFloat", Link: strPtr("Float")},
 		{Group: "LogNumberBin", Link: strPtr("Number")},
@@ -390,6 +445,14 @@ var DefaultConfig = Config{
 		{Group: "LogLvVerbose", Link: strPtr("Special")},
 		{Group: "LogLvPass", Link: strPtr("LogGreen")},
 		{Group: "LogLvSuccess", Link: strPtr("LogGreen")},
+		{Group: "LogJsonKey", Link: strPtr("Function")},
+		{Group: "LogJsonString", Link: strPtr("String")},
+		{Group: "LogJsonNumber", Link: strPtr("Number")},
+		{Group: "LogJsonBool", Link: strPtr("Boolean")},
+		{Group: "LogJsonNull", Link: strPtr("Constant")},
+		{Group: "LogJsonPunctuation", Link: strPtr("Operator")},
+		{Group: "LogfmtKey", Link: strPtr("Function")},
+		{Group: "LogfmtValue", Link: strPtr("String")},
 	},
 }
 
@@ -0,0 +1,251 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/madmaxieee/loglit/internal/proto"
+	"github.com/madmaxieee/loglit/internal/style"
+	"github.com/pelletier/go-toml/v2"
+)
+
+const ConfigPathEnv = "LOGLIT_CONFIG"
+
+// File is the on-disk representation of a loglit config file.
+//
+//	theme = "light"
+//	disable = ["LogSymbol", "LogNumberHex"]
+//	rainbow = ["LogUUID"]
+//
+//	[[syntax]]
+//	group = "UserPattern"
+//	pattern = 'ERR-\d+'
+//	ignore_case = true
+//
+//	[[syntax]]
+//	literals = ["[main]", "10.0.0.1"]
+//
+//	[[syntax]]
+//	pattern = '(?P<key>\w+)=(?P<val>\S+)'
+//	captures = { key = "LogfmtKey", val = "String" }
+//
+//	[[highlight]]
+//	group = "LogLvInfo"
+//	fg = "#00ff00"
+//	bold = true
+//
+//	[logfmt.keys.trace_id]
+//	bold = true
+//
+//	[pretty]
+//	template = "{time} {level} {msg}"
+//	time_format = "15:04:05"
+type File struct {
+	Theme     string                `toml:"theme"`
+	Disable   []string              `toml:"disable"`
+	Rainbow   []string              `toml:"rainbow"`
+	Syntax    []fileSyntax          `toml:"syntax"`
+	Highlight []style.HighlightSpec `toml:"highlight"`
+	Logfmt    fileLogfmt            `toml:"logfmt"`
+	Pretty    filePretty            `toml:"pretty"`
+}
+
+type filePretty struct {
+	Template   string `toml:"template"`
+	TimeFormat string `toml:"time_format"`
+}
+
+type fileLogfmt struct {
+	Enabled *bool                          `toml:"enabled"`
+	Keys    map[string]style.HighlightSpec `toml:"keys"`
+}
+
+type fileSyntax struct {
+	Group      string            `toml:"group"`
+	Pattern    proto.Pattern     `toml:"pattern"`
+	Keywords   []string          `toml:"keywords"`
+	Literals   []string          `toml:"literals"`
+	Captures   map[string]string `toml:"captures"`
+	IgnoreCase bool              `toml:"ignore_case"`
+	WholeWord  bool              `toml:"whole_word"`
+}
+
+// Dir returns $XDG_CONFIG_HOME/loglit, falling back to ~/.config when
+// XDG_CONFIG_HOME is unset.
+func Dir() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "loglit"), nil
+}
+
+// DefaultPath returns the config file loaded when none is given explicitly.
+func DefaultPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.toml"), nil
+}
+
+// ThemesDir returns the directory user theme files are loaded from.
+func ThemesDir() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "themes"), nil
+}
+
+// ReadFile parses the config file at path.
+func ReadFile(path string) (File, error) {
+	var file File
+	f, err := os.Open(path)
+	if err != nil {
+		return file, err
+	}
+	defer f.Close()
+
+	err = toml.NewDecoder(f).DisallowUnknownFields().Decode(&file)
+	if err != nil {
+		return file, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	for i, syn := range file.Syntax {
+		// patterns with captures may only style their capture groups
+		if syn.Group == "" && (len(syn.Captures) == 0 || len(syn.Keywords) > 0 || len(syn.Literals) > 0) {
+			file.Syntax[i].Group = "UserPattern"
+		}
+		if !syn.Pattern.HasValue() && len(syn.Keywords) == 0 && len(syn.Literals) == 0 {
+			return file, fmt.Errorf("config file %s: syntax entry %d has no pattern, keywords or literals", path, i+1)
+		}
+		err := proto.Syntax{Pattern: syn.Pattern, Captures: syn.Captures}.ValidateCaptures()
+		if err != nil {
+			return file, fmt.Errorf("config file %s: syntax entry %d: %w", path, i+1, err)
+		}
+	}
+	for i, hl := range file.Highlight {
+		if hl.Group == "" {
+			return file, fmt.Errorf("config file %s: highlight entry %d is missing a group", path, i+1)
+		}
+		if err := file.Highlight[i].ParseColors(); err != nil {
+			return file, fmt.Errorf("config file %s: highlight %q: %w", path, hl.Group, err)
+		}
+	}
+	for key, spec := range file.Logfmt.Keys {
+		if err := spec.ParseColors(); err != nil {
+			return file, fmt.Errorf("config file %s: logfmt key %q: %w", path, key, err)
+		}
+		file.Logfmt.Keys[key] = spec
+	}
+
+	return file, nil
+}
+
+// Apply merges the file on top of cfg. The theme replaces the configured one,
+// disabled groups are removed from the built-in syntax, rainbow groups are
+// added, syntax entries are appended to the user syntax, highlights are
+// appended so they override earlier definitions, logfmt key styles are added
+// as highlight groups and pretty settings replace the configured ones.
+func (file File) Apply(cfg *Config) {
+	if file.Theme != "" {
+		cfg.Theme = file.Theme
+	}
+	cfg.DisableGroups(file.Disable...)
+	cfg.Rainbow = append(slices.Clone(cfg.Rainbow), file.Rainbow...)
+	for _, syn := range file.Syntax {
+		cfg.UserSyntax = append(cfg.UserSyntax, syntax{
+			Group:      syn.Group,
+			Pattern:    syn.Pattern,
+			Keywords:   syn.Keywords,
+			Literals:   syn.Literals,
+			Captures:   syn.Captures,
+			IgnoreCase: syn.IgnoreCase,
+			WholeWord:  syn.WholeWord,
+			IsUser:     true,
+		})
+	}
+	for _, hl := range file.Highlight {
+		cfg.Highlight = append(cfg.Highlight, hl.Highlight())
+	}
+
+	if file.Logfmt.Enabled != nil {
+		cfg.Logfmt.Enabled = *file.Logfmt.Enabled
+	}
+	if len(file.Logfmt.Keys) > 0 {
+		cfg.Logfmt.KeyGroups = maps.Clone(cfg.Logfmt.KeyGroups)
+		if cfg.Logfmt.KeyGroups == nil {
+			cfg.Logfmt.KeyGroups = make(map[string]string)
+		}
+		for key, spec := range file.Logfmt.Keys {
+			spec.Group = LogfmtKeyGroup(key)
+			cfg.Logfmt.KeyGroups[key] = spec.Group
+			cfg.Highlight = append(cfg.Highlight, spec.Highlight())
+		}
+	}
+
+	if file.Pretty.Template != "" {
+		cfg.Pretty.Template = file.Pretty.Template
+	}
+	if file.Pretty.TimeFormat != "" {
+		cfg.Pretty.TimeFormat = file.Pretty.TimeFormat
+	}
+}
+
+// LogfmtKeyGroup returns the highlight group for the values of a logfmt key
+// styled in the config file.
+func LogfmtKeyGroup(key string) string {
+	return "LogfmtValue_" + key
+}
+
+// DisableGroups removes all built-in syntax belonging to the given groups.
+func (c *Config) DisableGroups(groups ...string) {
+	if len(groups) == 0 {
+		return
+	}
+	disabled := func(syn syntax) bool {
+		return slices.Contains(groups, syn.Group)
+	}
+	c.BuiltInSyntaxLower = slices.DeleteFunc(slices.Clone(c.BuiltInSyntaxLower), disabled)
+	c.BuiltInSyntax = slices.DeleteFunc(slices.Clone(c.BuiltInSyntax), disabled)
+}
+
+// Load returns the default config merged with the config file at path. When
+// path is empty the default location is used, and it is not an error for that
+// file to be missing.
+func Load(path string) (Config, error) {
+	cfg := GetDefaultConfig()
+
+	explicit := path != ""
+	if !explicit {
+		path = os.Getenv(ConfigPathEnv)
+		explicit = path != ""
+	}
+	if !explicit {
+		var err error
+		path, err = DefaultPath()
+		if err != nil {
+			return cfg, nil
+		}
+	}
+
+	file, err := ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return cfg, err
+	}
+
+	file.Apply(&cfg)
+	return cfg, nil
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := writeConfigFile(t, `
+disable = ["LogSymbol"]
+
+[[syntax]]
+pattern = 'ERR-\d+'
+
+[[syntax]]
+group = "Custom"
+keywords = ["foo", "bar"]
+ignore_case = true
+whole_word = true
+
+[[highlight]]
+group = "Custom"
+fg = "#ff0000"
+bold = true
+
+[[highlight]]
+group = "LogLvInfo"
+link = "LogGreen"
+
+[logfmt.keys.trace_id]
+bold = true
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	isSymbol := func(syn syntax) bool { return syn.Group == "LogSymbol" }
+	if slices.ContainsFunc(cfg.BuiltInSyntaxLower, isSymbol) || slices.ContainsFunc(cfg.BuiltInSyntax, isSymbol) {
+		t.Error("expected LogSymbol to be disabled")
+	}
+	if !slices.ContainsFunc(DefaultConfig.BuiltInSyntax, isSymbol) {
+		t.Error("disabling a group must not modify DefaultConfig")
+	}
+
+	if len(cfg.UserSyntax) != 2 {
+		t.Fatalf("expected 2 user syntax entries, got %d", len(cfg.UserSyntax))
+	}
+	if cfg.UserSyntax[0].Group != "UserPattern" {
+		t.Errorf("expected default group UserPattern, got %q", cfg.UserSyntax[0].Group)
+	}
+	if !cfg.UserSyntax[0].Pattern.MatchString("ERR-42") {
+		t.Error("expected pattern to match ERR-42")
+	}
+	if syn := cfg.UserSyntax[1]; syn.Group != "Custom" || len(syn.Keywords) != 2 || !syn.IgnoreCase || !syn.WholeWord {
+		t.Errorf("unexpected keyword syntax: %+v", cfg.UserSyntax[1])
+	}
+
+	n := len(cfg.Highlight)
+	custom, info, traceID := cfg.Highlight[n-3], cfg.Highlight[n-2], cfg.Highlight[n-1]
+	if custom.Fg == nil || *custom.Fg != "#ff0000" || !custom.Bold {
+		t.Errorf("unexpected custom highlight: %+v", custom)
+	}
+	if info.Link == nil || *info.Link != "LogGreen" {
+		t.Errorf("unexpected LogLvInfo highlight: %+v", info)
+	}
+	if group := cfg.Logfmt.KeyGroups["trace_id"]; group != traceID.Group || !traceID.Bold {
+		t.Errorf("unexpected trace_id style: group %q, highlight %+v", group, traceID)
+	}
+	if cfg.Logfmt.Enabled {
+		t.Error("expected logfmt to stay disabled without enabled = true")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "unknown field", content: `colour = "red"`},
+		{name: "invalid regex", content: "[[syntax]]\npattern = '('"},
+		{name: "empty syntax", content: "[[syntax]]\ngroup = \"UserPattern\""},
+		{name: "highlight without group", content: "[[highlight]]\nfg = \"#ffffff\""},
+		{name: "unknown capture", content: "[[syntax]]\npattern = '(?P<key>\\w+)'\ncaptures = { val = \"String\" }"},
+		{name: "capture out of range", content: "[[syntax]]\npattern = '(\\w+)'\ncaptures = { 2 = \"String\" }"},
+		{name: "invalid fg", content: "[[highlight]]\ngroup = \"LogLvInfo\"\nfg = \"#12345\""},
+		{name: "invalid bg", content: "[[highlight]]\ngroup = \"LogLvInfo\"\nbg = \"redd\""},
+		{name: "invalid logfmt key color", content: "[logfmt.keys.trace_id]\nfg = \"nope\""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeConfigFile(t, tt.content))
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestLoadColorNames(t *testing.T) {
+	cfg, err := Load(writeConfigFile(t, "[[highlight]]\ngroup = \"LogLvInfo\"\nfg = \"red\"\nbg = \"#00ff00\""))
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	hl := cfg.Highlight[len(cfg.Highlight)-1]
+	if hl.Fg == nil || *hl.Fg == "red" || hl.Bg == nil || *hl.Bg != "#00ff00" {
+		t.Errorf("expected hex colors, got %+v", hl)
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	t.Setenv(ConfigPathEnv, "")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if _, err := Load(""); err != nil {
+		t.Errorf("missing default config file should not be an error, got %v", err)
+	}
+
+	t.Setenv(ConfigPathEnv, filepath.Join(t.TempDir(), "missing.toml"))
+	if _, err := Load(""); err == nil {
+		t.Error("expected an error for a missing file set via " + ConfigPathEnv)
+	}
+}
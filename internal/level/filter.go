@@ -0,0 +1,57 @@
+package level
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UnknownPolicy decides what happens to lines without a detectable level.
+type UnknownPolicy int
+
+const (
+	// UnknownInherit uses the level of the previous line, which keeps stack
+	// traces and other continuation lines together with their first line.
+	UnknownInherit UnknownPolicy = iota
+	UnknownShow
+	UnknownHide
+)
+
+func ParseUnknownPolicy(s string) (UnknownPolicy, error) {
+	switch strings.ToLower(s) {
+	case "inherit":
+		return UnknownInherit, nil
+	case "show":
+		return UnknownShow, nil
+	case "hide":
+		return UnknownHide, nil
+	}
+	return UnknownInherit, fmt.Errorf("invalid unknown level policy %q, expected one of inherit, show, hide", s)
+}
+
+// Filter drops lines below a minimum level.
+type Filter struct {
+	Classifier *Classifier
+	Threshold  Level
+	Unknown    UnknownPolicy
+
+	previous Level
+}
+
+// Keep reports whether line should be written. Lines are expected in input
+// order since unknown levels may be inherited from the previous line.
+func (f *Filter) Keep(line string) bool {
+	l := f.Classifier.Classify(line)
+	if l != Unknown {
+		f.previous = l
+		return l >= f.Threshold
+	}
+
+	switch f.Unknown {
+	case UnknownShow:
+		return true
+	case UnknownHide:
+		return false
+	}
+	// nothing to inherit from before the first leveled line
+	return f.previous == Unknown || f.previous >= f.Threshold
+}
@@ -0,0 +1,162 @@
+package level
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/madmaxieee/loglit/internal/proto"
+)
+
+// Level is the severity of a log line, higher is more severe.
+type Level int
+
+const (
+	Unknown Level = iota
+	Trace
+	Debug
+	Info
+	Notice
+	Warn
+	Error
+	Fatal
+)
+
+var levelNames = map[Level]string{
+	Unknown: "unknown",
+	Trace:   "trace",
+	Debug:   "debug",
+	Info:    "info",
+	Notice:  "notice",
+	Warn:    "warn",
+	Error:   "error",
+	Fatal:   "fatal",
+}
+
+var levelAliases = map[string]Level{
+	"verbose":  Trace,
+	"dbg":      Debug,
+	"warning":  Warn,
+	"err":      Error,
+	"crit":     Fatal,
+	"critical": Fatal,
+}
+
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("Level(%d)", int(l))
+}
+
+// Parse parses a level name such as "warn" or "error".
+func Parse(s string) (Level, error) {
+	s = strings.ToLower(s)
+	for l, name := range levelNames {
+		if l != Unknown && name == s {
+			return l, nil
+		}
+	}
+	if l, ok := levelAliases[s]; ok {
+		return l, nil
+	}
+	return Unknown, fmt.Errorf("invalid level %q, expected one of trace, debug, info, notice, warn, error, fatal", s)
+}
+
+// groupLevels maps the built-in LogLv* highlight groups to levels.
+var groupLevels = map[string]Level{
+	"LogLvFatal":     Fatal,
+	"LogLvEmergency": Fatal,
+	"LogLvAlert":     Fatal,
+	"LogLvCritical":  Fatal,
+	"LogLvError":     Error,
+	"LogLvFail":      Error,
+	"LogLvFault":     Error,
+	"LogLvNack":      Error,
+	"LogLvWarning":   Warn,
+	"LogLvBad":       Warn,
+	"LogLvNotice":    Notice,
+	"LogLvInfo":      Info,
+	"LogLvPass":      Info,
+	"LogLvSuccess":   Info,
+	"LogLvDebug":     Debug,
+	"LogLvTrace":     Trace,
+	"LogLvVerbose":   Trace,
+}
+
+// GroupLevel returns the level of a LogLv* highlight group, or Unknown for
+// any other group.
+func GroupLevel(group string) Level {
+	return groupLevels[group]
+}
+
+var wordRe = regexp.MustCompile(proto.WordClass + `+`)
+
+type levelPattern struct {
+	pattern proto.Pattern
+	level   Level
+}
+
+// Classifier finds the most severe level mentioned in a line using the
+// keywords and patterns of the LogLv* syntax groups.
+type Classifier struct {
+	keywords map[string]Level
+	// keywords of syntax that ignores case, lowercased
+	folded   map[string]Level
+	patterns []levelPattern
+}
+
+// NewClassifier builds a Classifier from the LogLv* entries of syntaxList,
+// other entries are ignored.
+func NewClassifier(syntaxList []proto.Syntax) *Classifier {
+	c := &Classifier{keywords: make(map[string]Level), folded: make(map[string]Level)}
+	for _, syn := range syntaxList {
+		l := GroupLevel(syn.Group)
+		if l == Unknown {
+			continue
+		}
+		for _, keyword := range syn.Keywords {
+			if syn.IgnoreCase {
+				keyword = strings.ToLower(keyword)
+				c.folded[keyword] = max(c.folded[keyword], l)
+			} else {
+				c.keywords[keyword] = max(c.keywords[keyword], l)
+			}
+		}
+		if syn.Pattern.HasValue() {
+			pattern := syn.Pattern
+			if syn.IgnoreCase || syn.WholeWord {
+				pattern = pattern.WithOptions(syn.IgnoreCase, syn.WholeWord)
+			}
+			c.patterns = append(c.patterns, levelPattern{pattern: pattern, level: l})
+		}
+	}
+	return c
+}
+
+func (c *Classifier) keywordLevel(word string) Level {
+	l := c.keywords[word]
+	if len(c.folded) > 0 {
+		l = max(l, c.folded[strings.ToLower(word)])
+	}
+	return l
+}
+
+// Classify returns the most severe level found in line.
+func (c *Classifier) Classify(line string) Level {
+	result := Unknown
+	for _, word := range wordRe.FindAllString(line, -1) {
+		if l := c.keywordLevel(word); l > result {
+			result = l
+			if result == Fatal {
+				return result
+			}
+		}
+	}
+	for _, p := range c.patterns {
+		if p.level > result && p.pattern.MatchString(line) {
+			result = p.level
+		}
+	}
+	return result
+}
@@ -0,0 +1,69 @@
+package level
+
+import (
+	"testing"
+
+	"github.com/madmaxieee/loglit/internal/config"
+)
+
+func TestClassify(t *testing.T) {
+	c := NewClassifier(config.DefaultConfig.BuiltInSyntax)
+
+	tests := []struct {
+		line     string
+		expected Level
+	}{
+		{line: "2023-10-27 10:00:00 INFO started", expected: Info},
+		{line: "[warn] disk almost full", expected: Warn},
+		{line: "Warning: request failed", expected: Error},
+		{line: "APP_DEBUG something happened", expected: Debug},
+		{line: "FATAL out of memory", expected: Fatal},
+		{line: "wArN: disk almost full", expected: Warn},
+		{line: "e i d", expected: Unknown},
+		{line: "    at com.example.Main.run(Main.java:12)", expected: Unknown},
+	}
+
+	for _, tt := range tests {
+		got := c.Classify(tt.line)
+		if got != tt.expected {
+			t.Errorf("%q: expected %v, got %v", tt.line, tt.expected, got)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	lines := []string{
+		"starting up",
+		"INFO ready",
+		"ERROR request failed",
+		"    at handler.go:12",
+		"DEBUG retrying",
+		"    attempt 2",
+		"WARN slow response",
+	}
+
+	tests := []struct {
+		name     string
+		policy   UnknownPolicy
+		expected []bool
+	}{
+		{name: "inherit", policy: UnknownInherit, expected: []bool{true, false, true, true, false, false, true}},
+		{name: "show", policy: UnknownShow, expected: []bool{true, false, true, true, false, true, true}},
+		{name: "hide", policy: UnknownHide, expected: []bool{false, false, true, false, false, false, true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Filter{
+				Classifier: NewClassifier(config.DefaultConfig.BuiltInSyntax),
+				Threshold:  Warn,
+				Unknown:    tt.policy,
+			}
+			for i, line := range lines {
+				if got := f.Keep(line); got != tt.expected[i] {
+					t.Errorf("%q: expected keep=%v, got %v", line, tt.expected[i], got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package pretty
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultTemplate = "{time} {level} {logger}  {msg}"
+
+const DefaultTimeFormat = "15:04:05.000"
+
+// fieldAliases lists the keys commonly used for the well-known template
+// fields, the first one present in a record is used.
+var fieldAliases = map[string][]string{
+	"time":   {"time", "ts", "timestamp", "@timestamp"},
+	"level":  {"level", "lvl", "severity"},
+	"logger": {"logger", "name", "component", "service"},
+	"msg":    {"msg", "message"},
+	"error":  {"error", "err"},
+}
+
+var placeholderRe = regexp.MustCompile(`\{([^{}\s]+)\}`)
+
+type field struct {
+	key   string
+	value json.RawMessage
+}
+
+// Formatter turns JSON log records into compact human-readable lines. The
+// template picks fields by name, e.g. "{time} {level} {msg}", and all fields
+// not used by it are appended as key=value pairs.
+type Formatter struct {
+	Template   string
+	TimeFormat string
+}
+
+func New(template string, timeFormat string) *Formatter {
+	if template == "" {
+		template = DefaultTemplate
+	}
+	if timeFormat == "" {
+		timeFormat = DefaultTimeFormat
+	}
+	return &Formatter{Template: template, TimeFormat: timeFormat}
+}
+
+// Format returns the formatted line, or the line unchanged if it is not a
+// JSON object.
+func (f *Formatter) Format(line string) string {
+	fields, ok := parseObject(line)
+	if !ok {
+		return line
+	}
+
+	used := make(map[string]bool)
+	lookup := func(name string) (field, bool) {
+		keys, ok := fieldAliases[name]
+		if !ok {
+			keys = []string{name}
+		}
+		for _, key := range keys {
+			for _, fd := range fields {
+				if fd.key == key {
+					return fd, true
+				}
+			}
+		}
+		return field{}, false
+	}
+
+	var b strings.Builder
+	last := 0
+	skipSpace := false
+	for _, idx := range placeholderRe.FindAllStringSubmatchIndex(f.Template, -1) {
+		literal := f.Template[last:idx[0]]
+		if skipSpace {
+			literal = strings.TrimLeft(literal, " ")
+		}
+		b.WriteString(literal)
+		last = idx[1]
+
+		name := f.Template[idx[2]:idx[3]]
+		fd, ok := lookup(name)
+		if !ok {
+			skipSpace = true
+			continue
+		}
+		used[fd.key] = true
+		value := f.formatField(name, fd.value)
+		b.WriteString(value)
+		skipSpace = value == ""
+	}
+	literal := f.Template[last:]
+	if skipSpace {
+		literal = strings.TrimLeft(literal, " ")
+	}
+	b.WriteString(literal)
+
+	out := strings.TrimRight(b.String(), " ")
+	var rest strings.Builder
+	for _, fd := range fields {
+		if used[fd.key] {
+			continue
+		}
+		rest.WriteByte(' ')
+		rest.WriteString(fd.key)
+		rest.WriteByte('=')
+		rest.WriteString(formatValue(fd.value))
+	}
+	if out == "" {
+		return strings.TrimLeft(rest.String(), " ")
+	}
+	if rest.Len() > 0 {
+		out += " " + rest.String()
+	}
+	return out
+}
+
+func (f *Formatter) formatField(name string, raw json.RawMessage) string {
+	switch name {
+	case "time":
+		if t, ok := parseTime(raw); ok {
+			return t.Format(f.TimeFormat)
+		}
+	case "level":
+		return strings.ToUpper(escapeControl(unquote(raw)))
+	}
+	return escapeControl(unquote(raw))
+}
+
+// controlEscaper writes line breaks and tabs as escape sequences, so that
+// every record stays on a single line.
+var controlEscaper = strings.NewReplacer("\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+// escapeControl returns s with its line breaks and tabs escaped, for fields
+// of the template which are not quoted.
+func escapeControl(s string) string {
+	if !strings.ContainsAny(s, "\n\r\t") {
+		return s
+	}
+	return controlEscaper.Replace(s)
+}
+
+// parseObject decodes the top level fields of a JSON object in order.
+func parseObject(line string) ([]field, bool) {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "{") {
+		return nil, false
+	}
+	dec := json.NewDecoder(strings.NewReader(trimmed))
+	dec.UseNumber()
+	if _, err := dec.Token(); err != nil {
+		return nil, false
+	}
+	var fields []field
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, false
+		}
+		key, ok := token.(string)
+		if !ok {
+			return nil, false
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, false
+		}
+		fields = append(fields, field{key: key, value: value})
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, false
+	}
+	if dec.More() {
+		return nil, false
+	}
+	return fields, true
+}
+
+// parseTime parses RFC3339 strings and unix timestamps in seconds or
+// milliseconds.
+func parseTime(raw json.RawMessage) (time.Time, bool) {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		return t, err == nil
+	}
+	if ms, err := strconv.ParseInt(string(raw), 10, 64); err == nil && ms > 1e12 {
+		return time.UnixMilli(ms), true
+	}
+	n, err := strconv.ParseFloat(string(raw), 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if n > 1e12 {
+		n /= 1000
+	}
+	sec, frac := math.Modf(n)
+	return time.Unix(int64(sec), int64(frac*1e9)), true
+}
+
+// unquote returns strings without quotes and other values as compact JSON.
+func unquote(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var b bytes.Buffer
+	if json.Compact(&b, raw) != nil {
+		return string(raw)
+	}
+	return b.String()
+}
+
+// formatValue formats a value for a key=value pair, quoting strings that
+// would otherwise be ambiguous or span several lines.
+func formatValue(raw json.RawMessage) string {
+	s := unquote(raw)
+	if raw[0] == '"' && (s == "" || strings.ContainsAny(s, " \t\n\r\"=")) {
+		return strconv.Quote(s)
+	}
+	return s
+}
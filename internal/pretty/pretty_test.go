@@ -0,0 +1,73 @@
+package pretty
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		line     string
+		expected string
+	}{
+		{
+			name:     "default template",
+			line:     `{"time":"2024-05-01T12:01:02.123Z","level":"error","logger":"api","msg":"request failed","user":42,"path":"/x"}`,
+			expected: "12:01:02.123 ERROR api  request failed  user=42 path=/x",
+		},
+		{
+			name:     "missing fields",
+			line:     `{"lvl":"info","message":"started"}`,
+			expected: "INFO started",
+		},
+		{
+			name:     "quoted and nested values",
+			line:     `{"msg":"done","note":"a b","empty":"","tags":["x", "y"],"ok":true}`,
+			expected: `done  note="a b" empty="" tags=["x","y"] ok=true`,
+		},
+		{
+			name:     "custom template",
+			template: "[{level}] {msg} ({request_id})",
+			line:     `{"msg":"hi","level":"warn","request_id":"r1","extra":1}`,
+			expected: "[WARN] hi (r1)  extra=1",
+		},
+		{
+			name:     "epoch milliseconds",
+			template: "{time} {msg}",
+			line:     `{"ts":1714564862123,"msg":"x"}`,
+			expected: "12:01:02.123 x",
+		},
+		{
+			name:     "line breaks in msg",
+			line:     `{"msg":"a\nb\r\n\tc"}`,
+			expected: `a\nb\r\n\tc`,
+		},
+		{
+			name:     "line breaks in values",
+			line:     `{"msg":"boom","stack":"main.go:1\nmain.go:2","note":"a\rb"}`,
+			expected: `boom  stack="main.go:1\nmain.go:2" note="a\rb"`,
+		},
+		{
+			name:     "not json",
+			line:     `level=info msg="plain"`,
+			expected: `level=info msg="plain"`,
+		},
+		{
+			name:     "invalid json",
+			line:     `{"msg":"x"`,
+			expected: `{"msg":"x"`,
+		},
+	}
+
+	time.Local = time.UTC
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := New(tt.template, "")
+			if got := f.Format(tt.line); got != tt.expected {
+				t.Errorf("Format(%q) = %q, want %q", tt.line, got, tt.expected)
+			}
+		})
+	}
+}
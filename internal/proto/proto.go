@@ -1,18 +1,49 @@
 package proto
 
 import (
+	"fmt"
 	"regexp"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
 )
 
 type Syntax struct {
 	Group    string
 	Pattern  Pattern
 	Keywords []string
+	// Literals are matched as plain strings, all literals of a syntax list
+	// are found in a single pass.
+	Literals []string
 	IsUser   bool
+	// Captures maps capture groups of Pattern, by name or number, to the
+	// highlight group of their text. The rest of the match uses Group, or is
+	// left unstyled when Group is empty.
+	Captures map[string]string
+	// IgnoreCase makes the pattern and keywords match regardless of case.
+	IgnoreCase bool
+	// WholeWord makes the pattern only match whole words. Keywords always
+	// match whole words.
+	WholeWord bool
+}
+
+// wordRunes are the runes words are made of, in regex syntax. They define
+// words for keywords, literals and patterns alike.
+const wordRunes = `\p{L}\p{M}\p{N}_`
+
+// WordClass is the regex character class of the runes of words.
+const WordClass = `[` + wordRunes + `]`
+
+// IsWordRune reports whether r is in WordClass.
+func IsWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsNumber(r)
 }
 
 type Pattern struct {
 	*regexp.Regexp
+	// whether matches must be whole words, the regex ends with a capture
+	// group of the rune following the match, which is not part of it
+	wholeWord bool
 }
 
 func (p *Pattern) UnmarshalText(text []byte) error {
@@ -25,6 +56,89 @@ func (p Pattern) HasValue() bool {
 	return p.Regexp != nil
 }
 
+// WithOptions returns p made case-insensitive and restricted to whole words
+// as requested. Capture group numbers are unchanged.
+func (p Pattern) WithOptions(ignoreCase, wholeWord bool) Pattern {
+	expr := p.String()
+	if wholeWord && !p.wholeWord {
+		// Go regexes only know ASCII word boundaries, so the end of a match
+		// is checked by the regex and its start by FindAllStringSubmatchIndex.
+		// The capture group comes last to keep the numbers of the others.
+		expr = `(?:` + expr + `)(?:([^` + wordRunes + `])|$)`
+	}
+	if ignoreCase {
+		expr = `(?i)` + expr
+	}
+	// p is a valid regex, so wrapping it cannot fail
+	return Pattern{Regexp: regexp.MustCompile(expr), wholeWord: wholeWord || p.wholeWord}
+}
+
+// NumSubexp returns the number of capture groups of the pattern.
+func (p Pattern) NumSubexp() int {
+	if p.wholeWord {
+		return p.Regexp.NumSubexp() - 1
+	}
+	return p.Regexp.NumSubexp()
+}
+
+// FindAllStringSubmatchIndex is like the method of regexp.Regexp, with only
+// whole words matching if p is restricted to them.
+func (p Pattern) FindAllStringSubmatchIndex(s string, n int) [][]int {
+	if !p.wholeWord {
+		return p.Regexp.FindAllStringSubmatchIndex(s, n)
+	}
+	next := 2*p.NumSubexp() + 2
+	all := p.Regexp.FindAllStringSubmatchIndex(s, -1)
+	result := all[:0]
+	for _, loc := range all {
+		if n >= 0 && len(result) == n {
+			break
+		}
+		if loc[0] > 0 {
+			r, _ := utf8.DecodeLastRuneInString(s[:loc[0]])
+			if IsWordRune(r) {
+				continue
+			}
+		}
+		if loc[next] >= 0 {
+			loc[1] = loc[next]
+		}
+		result = append(result, loc[:next])
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// FindAllStringIndex is like the method of regexp.Regexp, with only whole
+// words matching if p is restricted to them.
+func (p Pattern) FindAllStringIndex(s string, n int) [][]int {
+	if !p.wholeWord {
+		return p.Regexp.FindAllStringIndex(s, n)
+	}
+	all := p.FindAllStringSubmatchIndex(s, n)
+	for i, loc := range all {
+		all[i] = loc[:2]
+	}
+	return all
+}
+
+// MatchString is like the method of regexp.Regexp, with only whole words
+// matching if p is restricted to them.
+func (p Pattern) MatchString(s string) bool {
+	if !p.wholeWord {
+		return p.Regexp.MatchString(s)
+	}
+	return len(p.FindAllStringSubmatchIndex(s, -1)) > 0
+}
+
+// IsWholeWord reports whether p is restricted to whole words, its regex then
+// matches more than that.
+func (p Pattern) IsWholeWord() bool {
+	return p.wholeWord
+}
+
 func MustCompile(pattern string) Pattern {
 	return Pattern{Regexp: regexp.MustCompile(pattern)}
 }
@@ -36,3 +150,28 @@ func MustCompileAll(patterns ...string) []Pattern {
 	}
 	return result
 }
+
+// CaptureIndex returns the index of the capture group of p named or numbered
+// by key, or -1 if there is none.
+func (p Pattern) CaptureIndex(key string) int {
+	if n, err := strconv.Atoi(key); err == nil {
+		if n < 1 || n > p.NumSubexp() {
+			return -1
+		}
+		return n
+	}
+	return p.SubexpIndex(key)
+}
+
+// ValidateCaptures checks that every capture of syn exists in its pattern.
+func (syn Syntax) ValidateCaptures() error {
+	for key := range syn.Captures {
+		if !syn.Pattern.HasValue() {
+			return fmt.Errorf("capture %q requires a pattern", key)
+		}
+		if syn.Pattern.CaptureIndex(key) < 0 {
+			return fmt.Errorf("pattern %q has no capture group %q", syn.Pattern.String(), key)
+		}
+	}
+	return nil
+}
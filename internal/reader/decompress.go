@@ -0,0 +1,74 @@
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+)
+
+// decompressReader detects the compression of the underlying reader on the
+// first read, so that creating it never blocks waiting for input.
+type decompressReader struct {
+	r  *bufio.Reader
+	dr io.Reader
+}
+
+// Decompress returns a reader that transparently decompresses gzip, bzip2
+// and zlib data, detected by their magic bytes. Uncompressed data is passed
+// through unchanged.
+func Decompress(r io.Reader) io.Reader {
+	return &decompressReader{r: bufio.NewReader(r)}
+}
+
+func (d *decompressReader) Read(p []byte) (int, error) {
+	if d.dr == nil {
+		dr, err := detectCompression(d.r)
+		if err != nil {
+			return 0, err
+		}
+		d.dr = dr
+	}
+	return d.dr.Read(p)
+}
+
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+)
+
+// detectCompression sniffs only the bytes of the first read of r, waiting for
+// more would hold back a short first line of a stream until the next one.
+func detectCompression(r *bufio.Reader) (io.Reader, error) {
+	_, err := r.Peek(1)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	header, _ := r.Peek(min(4, r.Buffered()))
+
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		return gzip.NewReader(r)
+	case bytes.HasPrefix(header, bzip2Magic) && len(header) == 4 && header[3] >= '1' && header[3] <= '9':
+		return bzip2.NewReader(r), nil
+	case isZlibHeader(header):
+		return zlib.NewReader(r)
+	}
+	return r, nil
+}
+
+// isZlibHeader checks for a deflate stream with the default window size and
+// one of the compression levels zlib writes, which rules out text starting
+// with "x" followed by another printable character.
+func isZlibHeader(header []byte) bool {
+	if len(header) < 2 || header[0] != 0x78 {
+		return false
+	}
+	switch header[1] {
+	case 0x01, 0x9c, 0xda:
+		return true
+	}
+	return false
+}
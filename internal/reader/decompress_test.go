@@ -0,0 +1,115 @@
+package reader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"testing"
+	"time"
+)
+
+const decompressTestData = "hello\nworld\n"
+
+// printf 'hello\nworld\n' | bzip2
+var bzip2TestData = []byte{
+	0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0x6b, 0x5f,
+	0xb1, 0xdd, 0x00, 0x00, 0x02, 0x41, 0x80, 0x00, 0x10, 0x06, 0x44, 0x90,
+	0x80, 0x20, 0x00, 0x31, 0x0c, 0x08, 0x21, 0xa3, 0x69, 0x08, 0x07, 0x23,
+	0xae, 0x87, 0x8b, 0xb9, 0x22, 0x9c, 0x28, 0x48, 0x35, 0xaf, 0xd8, 0xee,
+	0x80,
+}
+
+func TestDecompress(t *testing.T) {
+	var gzipData bytes.Buffer
+	gw := gzip.NewWriter(&gzipData)
+	gw.Write([]byte(decompressTestData))
+	gw.Close()
+
+	var zlibData bytes.Buffer
+	zw := zlib.NewWriter(&zlibData)
+	zw.Write([]byte(decompressTestData))
+	zw.Close()
+
+	tests := []struct {
+		name     string
+		input    []byte
+		expected string
+	}{
+		{name: "plain", input: []byte(decompressTestData), expected: decompressTestData},
+		{name: "gzip", input: gzipData.Bytes(), expected: decompressTestData},
+		{name: "bzip2", input: bzip2TestData, expected: decompressTestData},
+		{name: "zlib", input: zlibData.Bytes(), expected: decompressTestData},
+		{name: "text starting with x", input: []byte("x^2 = 4\n"), expected: "x^2 = 4\n"},
+		{name: "text starting with BZh", input: []byte("BZh\n"), expected: "BZh\n"},
+		{name: "short", input: []byte("x"), expected: "x"},
+		{name: "empty", input: nil, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := io.ReadAll(Decompress(bytes.NewReader(tt.input)))
+			if err != nil {
+				t.Fatalf("read failed: %v", err)
+			}
+			if string(out) != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, out)
+			}
+		})
+	}
+}
+
+func TestDecompressShortFirstLine(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go pw.Write([]byte("ok\n"))
+
+	read := make(chan string)
+	go func() {
+		buf := make([]byte, 16)
+		n, _ := Decompress(pr).Read(buf)
+		read <- string(buf[:n])
+	}()
+	select {
+	case got := <-read:
+		if got != "ok\n" {
+			t.Errorf("expected %q, got %q", "ok\n", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("read blocked waiting for more input")
+	}
+}
+
+func TestReadTruncatedGzip(t *testing.T) {
+	var gzipData bytes.Buffer
+	gw := gzip.NewWriter(&gzipData)
+	gw.Write([]byte(decompressTestData))
+	gw.Close()
+	truncated := gzipData.Bytes()[:gzipData.Len()-4]
+
+	for name, read := range map[string]func(io.Reader) (<-chan []byte, func() error){
+		"chunks":  ReadChunks,
+		"batches": ReadBatches,
+	} {
+		t.Run(name, func(t *testing.T) {
+			chunks, readErr := read(Decompress(bytes.NewReader(truncated)))
+			for range chunks {
+			}
+			if err := readErr(); err == nil {
+				t.Error("expected an error reading a truncated gzip stream")
+			}
+
+			chunks, readErr = read(Decompress(bytes.NewReader(gzipData.Bytes())))
+			var out []byte
+			for chunk := range chunks {
+				out = append(out, chunk...)
+			}
+			if err := readErr(); err != nil {
+				t.Errorf("expected no error reading a complete gzip stream, got %v", err)
+			}
+			if string(out) != decompressTestData {
+				t.Errorf("expected %q, got %q", decompressTestData, out)
+			}
+		})
+	}
+}
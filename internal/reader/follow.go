@@ -0,0 +1,142 @@
+package reader
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"time"
+)
+
+const defaultFollowInterval = 250 * time.Millisecond
+
+// Follower reads a file like `tail -F`. Instead of returning io.EOF it waits
+// for more data to be appended, starts over when the file is truncated and
+// reopens the path when the file is rotated by renaming or recreating it.
+type Follower struct {
+	path     string
+	file     *os.File
+	offset   int64
+	interval time.Duration
+}
+
+// NewFollower opens the file at path for following. If lastLines is not
+// negative, reading starts at the last lastLines lines of the file instead of
+// at its beginning.
+func NewFollower(path string, lastLines int) (*Follower, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	f := &Follower{
+		path:     path,
+		file:     file,
+		interval: defaultFollowInterval,
+	}
+	if lastLines >= 0 {
+		f.offset, err = seekLastLines(file, lastLines)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// Read reads from the followed file, blocking until data is available.
+func (f *Follower) Read(p []byte) (int, error) {
+	for {
+		n, err := f.file.Read(p)
+		f.offset += int64(n)
+		if n > 0 {
+			return n, nil
+		}
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+
+		reopened, err := f.checkFile()
+		if err != nil {
+			return 0, err
+		}
+		if !reopened {
+			time.Sleep(f.interval)
+		}
+	}
+}
+
+// checkFile handles truncation and rotation after reaching the end of the
+// file, reporting whether reading should start over.
+func (f *Follower) checkFile() (bool, error) {
+	info, err := f.file.Stat()
+	if err != nil {
+		return false, err
+	}
+	if info.Size() < f.offset {
+		f.offset, err = f.file.Seek(0, io.SeekStart)
+		return true, err
+	}
+
+	pathInfo, err := os.Stat(f.path)
+	if err != nil || os.SameFile(info, pathInfo) {
+		// the path may be briefly missing while the file is being rotated
+		return false, nil
+	}
+
+	// lines may have been appended to the old file between reaching its end
+	// and the rotation, those are read before switching over
+	if info.Size() > f.offset {
+		return true, nil
+	}
+
+	file, err := os.Open(f.path)
+	if err != nil {
+		return false, nil
+	}
+	f.file.Close()
+	f.file = file
+	f.offset = 0
+	return true, nil
+}
+
+// Close closes the followed file.
+func (f *Follower) Close() error {
+	return f.file.Close()
+}
+
+// seekLastLines moves the file offset to the start of the last n lines and
+// returns the new offset. A trailing newline does not start another line.
+func seekLastLines(file *os.File, n int) (int64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+	if n == 0 {
+		return file.Seek(0, io.SeekEnd)
+	}
+
+	offset := size
+	remaining := n
+	buf := make([]byte, 4096)
+	for offset > 0 {
+		blockSize := min(int64(len(buf)), offset)
+		offset -= blockSize
+		block := buf[:blockSize]
+		if _, err := file.ReadAt(block, offset); err != nil {
+			return 0, err
+		}
+		if offset+blockSize == size && bytes.HasSuffix(block, []byte{'\n'}) {
+			block = block[:len(block)-1]
+		}
+		for i := len(block) - 1; i >= 0; i-- {
+			if block[i] != '\n' {
+				continue
+			}
+			remaining--
+			if remaining == 0 {
+				return file.Seek(offset+int64(i)+1, io.SeekStart)
+			}
+		}
+	}
+	return file.Seek(0, io.SeekStart)
+}
@@ -0,0 +1,106 @@
+package reader
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func readFollower(t *testing.T, f *Follower, expected string) {
+	t.Helper()
+	done := make(chan struct{})
+	buf := make([]byte, len(expected))
+	var err error
+	go func() {
+		_, err = io.ReadFull(f, buf)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for %q", expected)
+	}
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if string(buf) != expected {
+		t.Fatalf("expected %q, got %q", expected, buf)
+	}
+}
+
+func appendFile(t *testing.T, path string, data string) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(data); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+}
+
+func TestFollower(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "one\ntwo\n")
+
+	f, err := NewFollower(path, -1)
+	if err != nil {
+		t.Fatalf("failed to follow file: %v", err)
+	}
+	defer f.Close()
+	f.interval = time.Millisecond
+
+	readFollower(t, f, "one\ntwo\n")
+
+	appendFile(t, path, "three\n")
+	readFollower(t, f, "three\n")
+
+	// truncation
+	if err := os.WriteFile(path, []byte("four\n"), 0644); err != nil {
+		t.Fatalf("failed to truncate file: %v", err)
+	}
+	readFollower(t, f, "four\n")
+
+	// rotation by rename and recreate
+	appendFile(t, path, "five\n")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatalf("failed to rotate file: %v", err)
+	}
+	appendFile(t, path, "six\n")
+	readFollower(t, f, "five\nsix\n")
+}
+
+func TestFollowerLastLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "one\ntwo\nthree\n")
+
+	tests := []struct {
+		lines    int
+		expected string
+	}{
+		{lines: 1, expected: "three\n"},
+		{lines: 2, expected: "two\nthree\n"},
+		{lines: 10, expected: "one\ntwo\nthree\n"},
+	}
+
+	for _, tt := range tests {
+		f, err := NewFollower(path, tt.lines)
+		if err != nil {
+			t.Fatalf("failed to follow file: %v", err)
+		}
+		readFollower(t, f, tt.expected)
+		f.Close()
+	}
+
+	f, err := NewFollower(path, 0)
+	if err != nil {
+		t.Fatalf("failed to follow file: %v", err)
+	}
+	defer f.Close()
+	f.interval = time.Millisecond
+	appendFile(t, path, "four\n")
+	readFollower(t, f, "four\n")
+}
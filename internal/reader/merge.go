@@ -0,0 +1,183 @@
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"io"
+	"time"
+)
+
+// entryReader splits a stream into entries, each being a line with a
+// timestamp followed by the lines without one, such as stack traces.
+type entryReader struct {
+	r     *bufio.Reader
+	parse func(line string) (time.Time, bool)
+	// first line of the next entry, read ahead while looking for the end of
+	// the current one
+	next     []byte
+	nextTime time.Time
+	eof      bool
+	// timestamp of the last entry with a date, which lines with only a time
+	// of day are assumed to follow
+	lastDated time.Time
+}
+
+// readLine returns the next line, always ending in a newline so that the
+// last line of the stream is written as soon as it is emitted.
+func (er *entryReader) readLine() ([]byte, error) {
+	line, err := er.r.ReadBytes('\n')
+	if err == io.EOF {
+		er.eof = true
+		err = nil
+		if len(line) > 0 {
+			line = append(line, '\n')
+		}
+	}
+	return line, err
+}
+
+func (er *entryReader) parseLine(line []byte) (time.Time, bool) {
+	t, ok := er.parse(string(bytes.TrimRight(line, "\r\n")))
+	if !ok {
+		return t, false
+	}
+	if !hasDate(t) {
+		if er.lastDated.IsZero() {
+			return t, true
+		}
+		y, m, d := er.lastDated.Date()
+		t = time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), er.lastDated.Location())
+		// the stream is in order, so an earlier time of day is on the next day
+		if t.Before(er.lastDated) {
+			t = t.AddDate(0, 0, 1)
+		}
+	}
+	er.lastDated = t
+	return t, true
+}
+
+// hasDate reports whether t has a date, parsers give lines with only a time
+// of day the zero date.
+func hasDate(t time.Time) bool {
+	y, m, d := t.Date()
+	return y != 1 || m != time.January || d != 1
+}
+
+// readEntry returns the next entry and its timestamp. Lines before the first
+// timestamp form an entry with a zero timestamp. Lines with only a time of
+// day take the date of the entry before them, if there is one. A nil entry
+// means the stream has ended.
+func (er *entryReader) readEntry() ([]byte, time.Time, error) {
+	if er.next == nil {
+		if er.eof {
+			return nil, time.Time{}, nil
+		}
+		line, err := er.readLine()
+		if err != nil || len(line) == 0 {
+			return nil, time.Time{}, err
+		}
+		er.next = line
+		er.nextTime, _ = er.parseLine(line)
+	}
+
+	entry, t := er.next, er.nextTime
+	er.next = nil
+	for !er.eof {
+		line, err := er.readLine()
+		if err != nil {
+			return entry, t, err
+		}
+		if len(line) == 0 {
+			break
+		}
+		if lineTime, ok := er.parseLine(line); ok {
+			er.next, er.nextTime = line, lineTime
+			break
+		}
+		entry = append(entry, line...)
+	}
+	return entry, t, nil
+}
+
+// SourceError is an error reading one of the readers of MergeByTime.
+type SourceError struct {
+	// index of the reader
+	Source int
+	Err    error
+}
+
+func (e *SourceError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+type mergeItem struct {
+	source int
+	entry  []byte
+	time   time.Time
+}
+
+type mergeHeap []mergeItem
+
+func (h mergeHeap) Len() int { return len(h) }
+
+func (h mergeHeap) Less(i, j int) bool {
+	if h[i].time.Equal(h[j].time) {
+		return h[i].source < h[j].source
+	}
+	return h[i].time.Before(h[j].time)
+}
+
+func (h mergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *mergeHeap) Push(x any) { *h = append(*h, x.(mergeItem)) }
+
+func (h *mergeHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// MergeByTime reads all readers and calls emit with their entries in
+// chronological order, using parse to find the timestamp of a line. Each
+// reader is expected to be in chronological order already, entries with equal
+// timestamps are emitted in the order of the readers. Errors reading a reader
+// are returned as a *SourceError.
+func MergeByTime(
+	readers []io.Reader,
+	parse func(line string) (time.Time, bool),
+	emit func(source int, entry []byte),
+) error {
+	entryReaders := make([]*entryReader, len(readers))
+	h := make(mergeHeap, 0, len(readers))
+	for i, r := range readers {
+		entryReaders[i] = &entryReader{r: bufio.NewReader(r), parse: parse}
+		entry, t, err := entryReaders[i].readEntry()
+		if err != nil {
+			return &SourceError{Source: i, Err: err}
+		}
+		if entry != nil {
+			h = append(h, mergeItem{source: i, entry: entry, time: t})
+		}
+	}
+	heap.Init(&h)
+
+	for h.Len() > 0 {
+		item := heap.Pop(&h).(mergeItem)
+		emit(item.source, item.entry)
+
+		entry, t, err := entryReaders[item.source].readEntry()
+		if err != nil {
+			return &SourceError{Source: item.source, Err: err}
+		}
+		if entry != nil {
+			heap.Push(&h, mergeItem{source: item.source, entry: entry, time: t})
+		}
+	}
+	return nil
+}
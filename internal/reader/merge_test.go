@@ -0,0 +1,102 @@
+package reader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/madmaxieee/loglit/internal/config"
+	"github.com/madmaxieee/loglit/internal/timestamp"
+)
+
+func TestMergeByTime(t *testing.T) {
+	parse := func(line string) (time.Time, bool) {
+		var h, m, s int
+		if _, err := fmt.Sscanf(line, "%d:%d:%d", &h, &m, &s); err != nil {
+			return time.Time{}, false
+		}
+		return time.Time{}.Add(time.Duration(h*3600+m*60+s) * time.Second), true
+	}
+
+	readers := []io.Reader{
+		strings.NewReader("header\n10:00:01 a1\n10:00:04 a2\n  at a.go:1\n"),
+		strings.NewReader("10:00:02 b1\n  at b.go:1\n  at b.go:2\n10:00:04 b2"),
+		strings.NewReader("10:00:03 c1\n"),
+	}
+
+	var out strings.Builder
+	err := MergeByTime(readers, parse, func(source int, entry []byte) {
+		out.WriteString(string(rune('a' + source)))
+		out.WriteString("|")
+		out.Write(entry)
+	})
+	if err != nil {
+		t.Fatalf("merge failed: %v", err)
+	}
+
+	expected := "a|header\n" +
+		"a|10:00:01 a1\n" +
+		"b|10:00:02 b1\n  at b.go:1\n  at b.go:2\n" +
+		"c|10:00:03 c1\n" +
+		"a|10:00:04 a2\n  at a.go:1\n" +
+		"b|10:00:04 b2\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestMergeByTimeDates(t *testing.T) {
+	parse := timestamp.NewParser(config.DefaultConfig.BuiltInSyntax).Parse
+
+	readers := []io.Reader{
+		// time of day only after a dated line, past midnight and without a
+		// trailing newline
+		strings.NewReader("2024-01-01 23:59:58 a1\n23:59:59 a2\n00:00:02 a3"),
+		strings.NewReader("2024-01-01 23:59:57 b1\n2024-01-02 00:00:01 b2\n2024-01-02 00:00:03 b3\n"),
+	}
+
+	var out strings.Builder
+	err := MergeByTime(readers, parse, func(source int, entry []byte) {
+		out.WriteString(string(rune('a' + source)))
+		out.WriteString("|")
+		out.Write(entry)
+	})
+	if err != nil {
+		t.Fatalf("merge failed: %v", err)
+	}
+
+	expected := "b|2024-01-01 23:59:57 b1\n" +
+		"a|2024-01-01 23:59:58 a1\n" +
+		"a|23:59:59 a2\n" +
+		"b|2024-01-02 00:00:01 b2\n" +
+		"a|00:00:02 a3\n" +
+		"b|2024-01-02 00:00:03 b3\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestMergeByTimeReadError(t *testing.T) {
+	var gzipData bytes.Buffer
+	gw := gzip.NewWriter(&gzipData)
+	gw.Write([]byte("10:00:02 b1\n10:00:03 b2\n"))
+	gw.Close()
+	truncated := gzipData.Bytes()[:gzipData.Len()-4]
+
+	readers := []io.Reader{
+		strings.NewReader("10:00:01 a1\n"),
+		Decompress(bytes.NewReader(truncated)),
+	}
+	parse := func(line string) (time.Time, bool) { return time.Time{}, true }
+	err := MergeByTime(readers, parse, func(source int, entry []byte) {})
+
+	var srcErr *SourceError
+	if !errors.As(err, &srcErr) || srcErr.Source != 1 {
+		t.Errorf("expected an error reading source 1, got %v", err)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"bufio"
 	"bytes"
 	"io"
+	"sync"
 
+	"github.com/madmaxieee/loglit/internal/level"
 	"github.com/madmaxieee/loglit/internal/renderer"
 )
 
@@ -15,13 +17,104 @@ type LineBuffer struct {
 	buf            []byte
 	coloredFlushed int
 	rawFlushed     int
+	prefix         string
+	format         func(line string) string
+	jobs           int
+	// lines waiting to be rendered and written
+	out []outputLine
+	// buffers the workers render lines into
+	rendered []*bytes.Buffer
+	// spans of out sent to the workers, nil until they are started
+	batches chan [2]int
+	// batches not rendered yet
+	pending sync.WaitGroup
+
+	filter      *Filter
+	levelFilter *level.Filter
+	// lines before the next match, kept for context
+	before [][]byte
+	// number of context lines still to be written after the last match
+	afterLeft int
+	// whether lines were dropped since the last written line
+	skipped bool
+	written bool
+}
+
+// outputLine is a line recorded for writing, or a context separator.
+type outputLine struct {
+	line []byte
+	// part of the line not yet written to the raw output
+	raw []byte
+	// whether partial output of the line must be cleared first
+	clear     bool
+	separator bool
 }
 
+// Filter selects which lines a LineBuffer writes, similar to grep.
+type Filter struct {
+	Match  func(line string) bool
+	Invert bool
+	// number of context lines to write before and after each matching line
+	Before int
+	After  int
+}
+
+// ContextSeparator is written between non-adjacent groups of lines when
+// filtering with context.
+const ContextSeparator = "--"
+
 // NewLineBuffer creates a new LineBuffer.
 func NewLineBuffer(renderer *renderer.Renderer) *LineBuffer {
 	return &LineBuffer{renderer: renderer}
 }
 
+// SetPrefix sets a string written before every line of the colored output,
+// such as the name of the input. The raw output is not prefixed.
+func (lb *LineBuffer) SetPrefix(prefix string) {
+	lb.prefix = prefix
+}
+
+// SetFormatter sets a function that rewrites every line before it is
+// rendered to the colored output, such as a pretty printer for JSON logs. The
+// raw output always receives the original line.
+func (lb *LineBuffer) SetFormatter(format func(line string) string) {
+	lb.format = format
+}
+
+// render formats and renders a line to the colored output.
+func (lb *LineBuffer) render(w io.Writer, line []byte) {
+	if lb.format != nil {
+		line = []byte(lb.format(string(line)))
+	}
+	lb.renderer.RenderTo(w, line)
+}
+
+// SetJobs makes the LineBuffer render the lines found by each
+// ProcessCompleteLines call with a pool of jobs goroutines, which is kept
+// until Finalize. The lines are still written in input order. This only pays
+// off if each call gets many lines, such as with ReadBatches.
+func (lb *LineBuffer) SetJobs(jobs int) {
+	lb.jobs = jobs
+}
+
+// SetFilter makes the LineBuffer only write lines selected by filter. Since
+// a partial line cannot be matched yet, FlushPending is a no-op while a filter
+// is set.
+func (lb *LineBuffer) SetFilter(filter *Filter) {
+	lb.filter = filter
+}
+
+// SetLevelFilter makes the LineBuffer drop lines below the level threshold of
+// filter before any other filtering. Like SetFilter, it disables
+// FlushPending.
+func (lb *LineBuffer) SetLevelFilter(filter *level.Filter) {
+	lb.levelFilter = filter
+}
+
+func (lb *LineBuffer) filtering() bool {
+	return lb.filter != nil || lb.levelFilter != nil
+}
+
 // Append adds incoming data to the internal buffer.
 func (lb *LineBuffer) Append(data []byte) {
 	lb.buf = append(lb.buf, data...)
@@ -41,25 +134,149 @@ func (lb *LineBuffer) ProcessCompleteLines(coloredWriter, rawWriter *bufio.Write
 		if len(lineBytes) > 0 && lineBytes[len(lineBytes)-1] == '\r' {
 			lineBytes = lineBytes[:len(lineBytes)-1]
 		}
-		line := string(lineBytes)
+		lb.processLine(lineBytes)
 
-		if lb.coloredFlushed > 0 {
-			coloredWriter.WriteString("\033[2K\r")
+		lb.buf = lb.buf[idx+1:]
+		lb.coloredFlushed = 0
+		lb.rawFlushed = 0
+	}
+	lb.writeOutput(coloredWriter, rawWriter)
+}
+
+// processLine writes a complete line if it passes the filter, along with any
+// context lines. line is only valid until the recorded lines are written.
+func (lb *LineBuffer) processLine(line []byte) {
+	if lb.levelFilter != nil && !lb.levelFilter.Keep(string(line)) {
+		lb.skipped = true
+		return
+	}
+
+	f := lb.filter
+	if f == nil {
+		lb.writeLine(line)
+		return
+	}
+
+	if f.Match(string(line)) != f.Invert {
+		for _, contextLine := range lb.before {
+			lb.writeFilteredLine(contextLine)
 		}
-		coloredLine, _ := lb.renderer.Render(line)
-		coloredWriter.WriteString(coloredLine)
-		coloredWriter.WriteByte('\n')
+		lb.before = lb.before[:0]
+		lb.writeFilteredLine(line)
+		lb.afterLeft = f.After
+		return
+	}
+
+	if lb.afterLeft > 0 {
+		lb.writeFilteredLine(line)
+		lb.afterLeft--
+		return
+	}
+
+	if f.Before == 0 {
+		lb.skipped = true
+		return
+	}
+	if len(lb.before) == f.Before {
+		lb.before = append(lb.before[:0], lb.before[1:]...)
+		lb.skipped = true
+	}
+	lb.before = append(lb.before, bytes.Clone(line))
+}
+
+// writeFilteredLine writes a line selected by the filter, preceded by a
+// separator if lines were dropped since the previous one.
+func (lb *LineBuffer) writeFilteredLine(line []byte) {
+	f := lb.filter
+	if lb.skipped && lb.written && (f.Before > 0 || f.After > 0) {
+		lb.out = append(lb.out, outputLine{separator: true})
+	}
+	lb.skipped = false
+	lb.written = true
+	lb.writeLine(line)
+}
+
+// writeLine records a complete line for writing, replacing any partial
+// output previously written by FlushPending.
+func (lb *LineBuffer) writeLine(line []byte) {
+	raw := line
+	if lb.rawFlushed > 0 {
+		raw = line[lb.rawFlushed:]
+	}
+	lb.out = append(lb.out, outputLine{line: line, raw: raw, clear: lb.coloredFlushed > 0})
+}
 
-		if lb.rawFlushed > 0 {
-			rawWriter.WriteString(line[lb.rawFlushed:])
+// writeOutput renders the recorded lines and writes them in order.
+func (lb *LineBuffer) writeOutput(coloredWriter, rawWriter *bufio.Writer) {
+	parallel := lb.renderOutput()
+	for i, o := range lb.out {
+		if o.separator {
+			coloredWriter.WriteString(lb.prefix)
+			coloredWriter.WriteString(ContextSeparator)
+			coloredWriter.WriteByte('\n')
+			rawWriter.WriteString(ContextSeparator)
+			rawWriter.WriteByte('\n')
+			continue
+		}
+		if o.clear {
+			coloredWriter.WriteString("\033[2K\r")
+		}
+		coloredWriter.WriteString(lb.prefix)
+		if parallel {
+			coloredWriter.Write(lb.rendered[i].Bytes())
 		} else {
-			rawWriter.WriteString(line)
+			lb.render(coloredWriter, o.line)
 		}
+		coloredWriter.WriteByte('\n')
+		rawWriter.Write(o.raw)
 		rawWriter.WriteByte('\n')
+	}
+	clear(lb.out)
+	lb.out = lb.out[:0]
+}
 
-		lb.buf = lb.buf[idx+1:]
-		lb.coloredFlushed = 0
-		lb.rawFlushed = 0
+// renderBatchLines is the number of lines a worker renders at a time, enough
+// to make handing out the batches cheap compared to rendering them.
+const renderBatchLines = 64
+
+// renderOutput renders the recorded lines into lb.rendered with the pool of
+// lb.jobs workers. It returns false without rendering if there are not
+// enough lines to split between workers, in which case they are rendered
+// straight to the output.
+func (lb *LineBuffer) renderOutput() bool {
+	if lb.jobs <= 1 || len(lb.out) <= 1 {
+		return false
+	}
+	if lb.batches == nil {
+		lb.batches = make(chan [2]int, lb.jobs)
+		for range lb.jobs {
+			go lb.renderBatches(lb.batches)
+		}
+	}
+	for len(lb.rendered) < len(lb.out) {
+		lb.rendered = append(lb.rendered, new(bytes.Buffer))
+	}
+	// smaller batches for fewer lines, so that all workers get some
+	size := min(renderBatchLines, (len(lb.out)+lb.jobs-1)/lb.jobs)
+	for start := 0; start < len(lb.out); start += size {
+		lb.pending.Add(1)
+		lb.batches <- [2]int{start, min(start+size, len(lb.out))}
+	}
+	lb.pending.Wait()
+	return true
+}
+
+// renderBatches is a worker rendering spans of lb.out until batches is
+// closed.
+func (lb *LineBuffer) renderBatches(batches <-chan [2]int) {
+	for span := range batches {
+		for i := span[0]; i < span[1]; i++ {
+			if o := lb.out[i]; !o.separator {
+				lb.rendered[i].Reset()
+				lb.render(lb.rendered[i], o.line)
+			}
+		}
+		lb.pending.Done()
 	}
 }
 
@@ -71,52 +288,47 @@ func (lb *LineBuffer) ProcessCompleteLines(coloredWriter, rawWriter *bufio.Write
 // redrawn (after clearing the previous partial output) so that partial lines
 // appear colorized in real time.
 func (lb *LineBuffer) FlushPending(coloredWriter, rawWriter *bufio.Writer) {
-	if len(lb.buf) == 0 {
+	if len(lb.buf) == 0 || lb.filtering() {
 		return
 	}
-	pending := string(lb.buf)
+	pending := lb.buf
 	if coloredWriter != nil && len(pending) > lb.coloredFlushed {
 		coloredWriter.WriteString("\033[2K\r")
-		coloredLine, _ := lb.renderer.Render(pending)
-		coloredWriter.WriteString(coloredLine)
+		coloredWriter.WriteString(lb.prefix)
+		lb.render(coloredWriter, pending)
 		lb.coloredFlushed = len(pending)
 	}
 	if rawWriter != nil && len(pending) > lb.rawFlushed {
-		rawWriter.WriteString(pending[lb.rawFlushed:])
+		rawWriter.Write(pending[lb.rawFlushed:])
 		lb.rawFlushed = len(pending)
 	}
 }
 
 // Finalize treats any remaining buffered data as a final line and writes it
-// to the writers, even if it lacks a trailing newline.
+// to the writers, even if it lacks a trailing newline. It also stops the
+// workers started by SetJobs.
 func (lb *LineBuffer) Finalize(coloredWriter, rawWriter *bufio.Writer) {
-	if len(lb.buf) == 0 {
-		return
-	}
-	line := string(lb.buf)
-	if lb.coloredFlushed > 0 {
-		coloredWriter.WriteString("\033[2K\r")
-	}
-	coloredLine, _ := lb.renderer.Render(line)
-	coloredWriter.WriteString(coloredLine)
-	coloredWriter.WriteByte('\n')
+	if len(lb.buf) > 0 {
+		lb.processLine(lb.buf)
+		lb.writeOutput(coloredWriter, rawWriter)
 
-	if lb.rawFlushed > 0 {
-		rawWriter.WriteString(line[lb.rawFlushed:])
-	} else {
-		rawWriter.WriteString(line)
+		lb.buf = nil
+		lb.coloredFlushed = 0
+		lb.rawFlushed = 0
+	}
+	if lb.batches != nil {
+		close(lb.batches)
+		lb.batches = nil
 	}
-	rawWriter.WriteByte('\n')
-
-	lb.buf = nil
-	lb.coloredFlushed = 0
-	lb.rawFlushed = 0
 }
 
 // ReadChunks reads data from the provided reader in chunks and sends them
-// on the returned channel. The channel is closed when reading is done.
-func ReadChunks(r io.Reader) <-chan []byte {
+// on the returned channel as soon as they arrive. The channel is closed when
+// reading is done, after which the returned function gives the error that
+// ended it, or nil at the end of the input.
+func ReadChunks(r io.Reader) (<-chan []byte, func() error) {
 	chunkCh := make(chan []byte)
+	var readErr error
 	go func() {
 		defer close(chunkCh)
 		buf := make([]byte, 4096)
@@ -128,9 +340,55 @@ func ReadChunks(r io.Reader) <-chan []byte {
 				chunkCh <- chunk
 			}
 			if err != nil {
+				if err != io.EOF {
+					readErr = err
+				}
+				return
+			}
+		}
+	}()
+	return chunkCh, func() error { return readErr }
+}
+
+// BatchSize is the size of the chunks sent by ReadBatches.
+const BatchSize = 256 << 10
+
+// ReadBatches is like ReadChunks, but waits for BatchSize bytes before
+// sending a chunk, so that every chunk holds many lines to render in
+// parallel. It is meant for files, where waiting does not delay the output.
+func ReadBatches(r io.Reader) (<-chan []byte, func() error) {
+	chunkCh := make(chan []byte)
+	var readErr error
+	go func() {
+		defer close(chunkCh)
+		for {
+			chunk := make([]byte, BatchSize)
+			n, err := readFull(r, chunk)
+			if n > 0 {
+				chunkCh <- chunk[:n]
+			}
+			if err != nil {
+				if err != io.EOF {
+					readErr = err
+				}
 				return
 			}
 		}
 	}()
-	return chunkCh
+	return chunkCh, func() error { return readErr }
+}
+
+// readFull is like io.ReadFull, but passes on the errors of r as they are.
+// The end of the input is then always io.EOF, while io.ErrUnexpectedEOF of a
+// truncated compressed input is still an error.
+func readFull(r io.Reader, buf []byte) (int, error) {
+	n := 0
+	for n < len(buf) {
+		m, err := r.Read(buf[n:])
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
 }
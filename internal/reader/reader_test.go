@@ -0,0 +1,180 @@
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/madmaxieee/loglit/internal/config"
+	"github.com/madmaxieee/loglit/internal/renderer"
+	"github.com/madmaxieee/loglit/internal/theme"
+)
+
+func newTestLineBuffer(t *testing.T) *LineBuffer {
+	t.Helper()
+	r, err := renderer.New(config.GetDefaultConfig(), theme.GetDefaultTheme())
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+	r.NoColor = true
+	return NewLineBuffer(r)
+}
+
+func process(lb *LineBuffer, input string) (string, string) {
+	var colored, raw bytes.Buffer
+	coloredWriter := bufio.NewWriter(&colored)
+	rawWriter := bufio.NewWriter(&raw)
+	lb.Append([]byte(input))
+	lb.ProcessCompleteLines(coloredWriter, rawWriter)
+	lb.Finalize(coloredWriter, rawWriter)
+	coloredWriter.Flush()
+	rawWriter.Flush()
+	return colored.String(), raw.String()
+}
+
+func TestLineBuffer(t *testing.T) {
+	lb := newTestLineBuffer(t)
+	colored, raw := process(lb, "one\r\ntwo\nthree")
+	expected := "one\ntwo\nthree\n"
+	if colored != expected {
+		t.Errorf("expected colored output %q, got %q", expected, colored)
+	}
+	if raw != expected {
+		t.Errorf("expected raw output %q, got %q", expected, raw)
+	}
+}
+
+func TestLineBufferFilter(t *testing.T) {
+	input := "a\nb\nERR 1\nc\nd\ne\nf\nERR 2\ng\nERR 3\nh\n"
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected string
+	}{
+		{
+			name:     "matches only",
+			filter:   Filter{},
+			expected: "ERR 1\nERR 2\nERR 3\n",
+		},
+		{
+			name:     "inverted",
+			filter:   Filter{Invert: true},
+			expected: "a\nb\nc\nd\ne\nf\ng\nh\n",
+		},
+		{
+			name:     "context",
+			filter:   Filter{Before: 1, After: 1},
+			expected: "b\nERR 1\nc\n--\nf\nERR 2\ng\nERR 3\nh\n",
+		},
+		{
+			name:     "adjacent context",
+			filter:   Filter{Before: 2, After: 2},
+			expected: "a\nb\nERR 1\nc\nd\ne\nf\nERR 2\ng\nERR 3\nh\n",
+		},
+		{
+			name:     "after context",
+			filter:   Filter{After: 1},
+			expected: "ERR 1\nc\n--\nERR 2\ng\nERR 3\nh\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lb := newTestLineBuffer(t)
+			filter := tt.filter
+			filter.Match = func(line string) bool { return strings.HasPrefix(line, "ERR") }
+			lb.SetFilter(&filter)
+
+			colored, raw := process(lb, input)
+			if colored != tt.expected {
+				t.Errorf("expected colored output %q, got %q", tt.expected, colored)
+			}
+			if raw != tt.expected {
+				t.Errorf("expected raw output %q, got %q", tt.expected, raw)
+			}
+		})
+	}
+}
+
+func TestLineBufferFormatter(t *testing.T) {
+	lb := newTestLineBuffer(t)
+	lb.SetFormatter(strings.ToUpper)
+	colored, raw := process(lb, "one\ntwo\n")
+	if expected := "ONE\nTWO\n"; colored != expected {
+		t.Errorf("expected colored output %q, got %q", expected, colored)
+	}
+	if expected := "one\ntwo\n"; raw != expected {
+		t.Errorf("expected raw output %q, got %q", expected, raw)
+	}
+}
+
+func TestLineBufferJobs(t *testing.T) {
+	r, err := renderer.New(config.GetDefaultConfig(), theme.GetDefaultTheme())
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+	var input strings.Builder
+	for i := range 200 {
+		fmt.Fprintf(&input, "2023-10-27 10:00:%02d INFO line %d took %dms from 10.0.0.%d\n", i%60, i, i*7, i%256)
+		if i%17 == 0 {
+			input.WriteString("ERROR something failed: true\n")
+		}
+	}
+
+	output := func(jobs int) (string, string) {
+		lb := NewLineBuffer(r)
+		lb.SetJobs(jobs)
+		lb.SetPrefix("[app] ")
+		lb.SetFilter(&Filter{
+			Match:  func(line string) bool { return strings.Contains(line, "ERROR") },
+			Before: 2,
+			After:  1,
+		})
+		return process(lb, input.String())
+	}
+
+	expectedColored, expectedRaw := output(1)
+	colored, raw := output(8)
+	if colored != expectedColored {
+		t.Error("colored output with 8 jobs differs from a single job")
+	}
+	if raw != expectedRaw {
+		t.Error("raw output with 8 jobs differs from a single job")
+	}
+	if !strings.Contains(raw, ContextSeparator+"\n") {
+		t.Error("expected context separators in the output")
+	}
+}
+
+func BenchmarkLineBufferJobs(b *testing.B) {
+	r, err := renderer.New(config.GetDefaultConfig(), theme.GetDefaultTheme())
+	if err != nil {
+		b.Fatalf("failed to create renderer: %v", err)
+	}
+	var input bytes.Buffer
+	for i := 0; input.Len() < 2*BatchSize; i++ {
+		fmt.Fprintf(&input, "2023-10-27 10:00:%02d INFO [worker-%d] request 123e4567-e89b-12d3-a456-426614174000 took %dms from 10.0.0.%d ok=true\n", i%60, i%8, i*7, i%256)
+	}
+
+	for _, jobs := range []int{1, max(2, runtime.GOMAXPROCS(0))} {
+		b.Run(fmt.Sprintf("jobs=%d", jobs), func(b *testing.B) {
+			b.SetBytes(int64(input.Len()))
+			for b.Loop() {
+				lb := NewLineBuffer(r)
+				lb.SetJobs(jobs)
+				colored := bufio.NewWriter(io.Discard)
+				raw := bufio.NewWriter(io.Discard)
+				chunks, _ := ReadBatches(bytes.NewReader(input.Bytes()))
+				for chunk := range chunks {
+					lb.Append(chunk)
+					lb.ProcessCompleteLines(colored, raw)
+				}
+				lb.Finalize(colored, raw)
+			}
+		})
+	}
+}
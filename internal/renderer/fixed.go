@@ -0,0 +1,159 @@
+package renderer
+
+import (
+	"regexp/syntax"
+	"unicode"
+	"unicode/utf8"
+)
+
+// fixedElem is one byte of a fixed-width pattern, or a zero-width assertion
+// if assert is not zero.
+type fixedElem struct {
+	set    byteSet
+	assert syntax.EmptyOp
+}
+
+// fixedPattern is a pattern that always matches the same number of ASCII
+// bytes, such as `\b\d{4}-\d{2}-\d{2}\b`. It is matched by comparing bytes
+// instead of running the regex engine, with the same results.
+type fixedPattern []fixedElem
+
+// compileFixed returns the fixed pattern equivalent to expr, or nil if expr
+// uses anything but ASCII literals, ASCII character classes, word boundaries,
+// text anchors and groups.
+func compileFixed(expr string) fixedPattern {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	p, ok := appendFixed(nil, re.Simplify())
+	if !ok {
+		return nil
+	}
+	for _, elem := range p {
+		if elem.assert == 0 {
+			return p
+		}
+	}
+	// empty matches are left to the regex engine
+	return nil
+}
+
+func appendFixed(p fixedPattern, re *syntax.Regexp) (fixedPattern, bool) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			if r >= utf8.RuneSelf {
+				return nil, false
+			}
+			var s byteSet
+			s.add(byte(r))
+			if re.Flags&syntax.FoldCase != 0 {
+				for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+					if f >= utf8.RuneSelf {
+						// e.g. k also matches the Kelvin sign
+						return nil, false
+					}
+					s.add(byte(f))
+				}
+			}
+			p = append(p, fixedElem{set: s})
+		}
+		return p, true
+
+	case syntax.OpCharClass:
+		var s byteSet
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			if re.Rune[i+1] >= utf8.RuneSelf {
+				return nil, false
+			}
+			s.addRange(byte(re.Rune[i]), byte(re.Rune[i+1]))
+		}
+		return append(p, fixedElem{set: s}), true
+
+	case syntax.OpWordBoundary:
+		return append(p, fixedElem{assert: syntax.EmptyWordBoundary}), true
+	case syntax.OpNoWordBoundary:
+		return append(p, fixedElem{assert: syntax.EmptyNoWordBoundary}), true
+	case syntax.OpBeginText:
+		return append(p, fixedElem{assert: syntax.EmptyBeginText}), true
+	case syntax.OpEndText:
+		return append(p, fixedElem{assert: syntax.EmptyEndText}), true
+
+	case syntax.OpCapture:
+		return appendFixed(p, re.Sub[0])
+
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			var ok bool
+			p, ok = appendFixed(p, sub)
+			if !ok {
+				return nil, false
+			}
+		}
+		return p, true
+	}
+	return nil, false
+}
+
+// isWordByte matches regexp/syntax.IsWordChar, a non-ASCII byte is never
+// part of a word character.
+func isWordByte(text string, i int) bool {
+	if i < 0 || i >= len(text) {
+		return false
+	}
+	c := text[i]
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// matchAt returns the end of the match starting at text[start].
+func (p fixedPattern) matchAt(text string, start int) (int, bool) {
+	i := start
+	for _, elem := range p {
+		switch elem.assert {
+		case 0:
+			if i >= len(text) || !elem.set.has(text[i]) {
+				return 0, false
+			}
+			i++
+		case syntax.EmptyWordBoundary:
+			if isWordByte(text, i-1) == isWordByte(text, i) {
+				return 0, false
+			}
+		case syntax.EmptyNoWordBoundary:
+			if isWordByte(text, i-1) != isWordByte(text, i) {
+				return 0, false
+			}
+		case syntax.EmptyBeginText:
+			if i != 0 {
+				return 0, false
+			}
+		case syntax.EmptyEndText:
+			if i != len(text) {
+				return 0, false
+			}
+		}
+	}
+	return i, true
+}
+
+// next returns the span of the leftmost match in text that starts at or after
+// from.
+func (p fixedPattern) next(text string, from int) (int, int, bool) {
+	for i := from; i < len(text); i++ {
+		if end, ok := p.matchAt(text, i); ok {
+			return i, end, true
+		}
+	}
+	return 0, 0, false
+}
+
+// findAll returns the spans of the leftmost non-overlapping matches in text,
+// like regexp.FindAllStringIndex.
+func (p fixedPattern) findAll(text string) [][2]int {
+	var spans [][2]int
+	for start, end, ok := p.next(text, 0); ok; start, end, ok = p.next(text, end) {
+		spans = append(spans, [2]int{start, end})
+	}
+	return spans
+}
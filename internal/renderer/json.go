@@ -0,0 +1,225 @@
+package renderer
+
+import (
+	"encoding/json"
+	"strings"
+	"unsafe"
+
+	"github.com/madmaxieee/loglit/internal/style"
+)
+
+// jsonHighlights are the highlight groups used for JSON tokens.
+type jsonHighlights struct {
+	key         *style.Highlight
+	str         *style.Highlight
+	number      *style.Highlight
+	boolean     *style.Highlight
+	null        *style.Highlight
+	punctuation *style.Highlight
+	time        *style.Highlight
+	error       *style.Highlight
+}
+
+func newJSONHighlights(highlights map[string]*style.Highlight) (*jsonHighlights, error) {
+	var err error
+	get := func(group string) *style.Highlight {
+		hl, getErr := getHighlight(highlights, group)
+		if err == nil {
+			err = getErr
+		}
+		return hl
+	}
+	hls := &jsonHighlights{
+		key:         get("LogJsonKey"),
+		str:         get("LogJsonString"),
+		number:      get("LogJsonNumber"),
+		boolean:     get("LogJsonBool"),
+		null:        get("LogJsonNull"),
+		punctuation: get("LogJsonPunctuation"),
+		time:        get("LogDate"),
+		error:       get("LogLvError"),
+	}
+	return hls, err
+}
+
+// jsonField is the kind of a well-known top level field.
+type jsonField int
+
+const (
+	jsonFieldOther jsonField = iota
+	jsonFieldLevel
+	jsonFieldMessage
+	jsonFieldTime
+	jsonFieldError
+)
+
+var jsonFields = map[string]jsonField{
+	"level":      jsonFieldLevel,
+	"lvl":        jsonFieldLevel,
+	"severity":   jsonFieldLevel,
+	"msg":        jsonFieldMessage,
+	"message":    jsonFieldMessage,
+	"time":       jsonFieldTime,
+	"ts":         jsonFieldTime,
+	"timestamp":  jsonFieldTime,
+	"@timestamp": jsonFieldTime,
+	"error":      jsonFieldError,
+	"err":        jsonFieldError,
+}
+
+// IsJSONObject reports whether text is a single valid JSON object.
+func IsJSONObject(text string) bool {
+	trimmed := strings.TrimSpace(text)
+	// json.Valid only reads its input, so trimmed is not copied
+	return strings.HasPrefix(trimmed, "{") && json.Valid(unsafe.Slice(unsafe.StringData(trimmed), len(trimmed)))
+}
+
+func newMatch(start, end int, hl *style.Highlight) Match {
+	return Match{
+		Start:     start,
+		End:       end,
+		AnsiStart: hl.BuildAnsi(),
+		AnsiEnd:   hl.BuildAnsiReset(),
+	}
+}
+
+// findJSONMatches tokenizes a line holding a JSON object. Well-known top level
+// fields are styled by meaning: levels with their LogLv* group, times as
+// dates, errors as errors, and messages with the built-in syntax. It returns
+// false if the line is not a JSON object.
+func (r Renderer) findJSONMatches(s *scratch, text string) (MatchLayer, bool, error) {
+	if !IsJSONObject(text) {
+		return nil, false, nil
+	}
+
+	hls := r.jsonHighlights
+	matches := s.layer()
+	// true for objects, false for arrays
+	stack := s.jsonStack[:0]
+	defer func() { s.jsonStack = stack }()
+	expectKey := false
+	field := jsonFieldOther
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '{' || c == '[':
+			stack = append(stack, c == '{')
+			expectKey = c == '{'
+			*matches = append(*matches, newMatch(i, i+1, hls.punctuation))
+			i++
+
+		case c == '}' || c == ']':
+			stack = stack[:len(stack)-1]
+			*matches = append(*matches, newMatch(i, i+1, hls.punctuation))
+			i++
+
+		case c == ',':
+			expectKey = len(stack) > 0 && stack[len(stack)-1]
+			*matches = append(*matches, newMatch(i, i+1, hls.punctuation))
+			i++
+
+		case c == ':':
+			expectKey = false
+			*matches = append(*matches, newMatch(i, i+1, hls.punctuation))
+			i++
+
+		case c == '"':
+			end := jsonStringEnd(text, i)
+			if expectKey {
+				field = jsonFieldOther
+				if len(stack) == 1 {
+					field = jsonFields[text[i+1:end-1]]
+				}
+				*matches = append(*matches, newMatch(i, end, hls.key))
+				i = end
+				continue
+			}
+
+			err := r.appendJSONStringMatches(s, matches, text, i, end, field)
+			if err != nil {
+				return nil, false, err
+			}
+			field = jsonFieldOther
+			i = end
+
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(text) && strings.IndexByte("0123456789.eE+-", text[end]) >= 0 {
+				end++
+			}
+			hl := hls.number
+			if field == jsonFieldTime {
+				hl = hls.time
+			}
+			*matches = append(*matches, newMatch(i, end, hl))
+			field = jsonFieldOther
+			i = end
+
+		case c == 't' || c == 'f' || c == 'n':
+			end := i + 1
+			for end < len(text) && text[end] >= 'a' && text[end] <= 'z' {
+				end++
+			}
+			hl := hls.boolean
+			if c == 'n' {
+				hl = hls.null
+			}
+			*matches = append(*matches, newMatch(i, end, hl))
+			field = jsonFieldOther
+			i = end
+
+		default:
+			i++
+		}
+	}
+
+	return *matches, true, nil
+}
+
+// appendJSONStringMatches highlights the string value text[start:end],
+// including its quotes, according to the field it belongs to.
+func (r Renderer) appendJSONStringMatches(s *scratch, matches *MatchLayer, text string, start, end int, field jsonField) error {
+	hls := r.jsonHighlights
+	switch field {
+	case jsonFieldLevel:
+		if hl, ok := r.builtin.keywords.lookup(text[start+1 : end-1]); ok {
+			*matches = append(*matches, newMatch(start, end, hl))
+			return nil
+		}
+	case jsonFieldTime:
+		*matches = append(*matches, newMatch(start, end, hls.time))
+		return nil
+	case jsonFieldError:
+		*matches = append(*matches, newMatch(start, end, hls.error))
+		return nil
+	case jsonFieldMessage:
+		// messages are free text, highlight them like any other line
+		inner, err := r.findBuiltinMatches(s, s.line(text[start+1:end-1]))
+		if err != nil {
+			return err
+		}
+		for _, match := range inner {
+			match.Start += start + 1
+			match.End += start + 1
+			*matches = append(*matches, match)
+		}
+		return nil
+	}
+	*matches = append(*matches, newMatch(start, end, hls.str))
+	return nil
+}
+
+// jsonStringEnd returns the index after the closing quote of the JSON string
+// starting at text[start].
+func jsonStringEnd(text string, start int) int {
+	for i := start + 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(text)
+}
@@ -1,26 +1,75 @@
 package renderer
 
 import (
+	"fmt"
 	"regexp"
+	"strings"
 
+	"github.com/madmaxieee/loglit/internal/proto"
 	"github.com/madmaxieee/loglit/internal/style"
 )
 
-var unicodeWordRe = regexp.MustCompile(`[\p{L}\p{M}\p{N}_]+`)
+var unicodeWordRe = regexp.MustCompile(proto.WordClass + `+`)
 
 func IsValidKeyword(s string) bool {
 	return unicodeWordRe.MatchString(s)
 }
 
+// keywordMap maps keywords to their highlight. Keywords of syntax that
+// ignores case are stored lowercased in folded.
+type keywordMap struct {
+	exact  map[string]*style.Highlight
+	folded map[string]*style.Highlight
+}
+
+func newKeywordMap(syntaxList []proto.Syntax, highlights map[string]*style.Highlight) (keywordMap, error) {
+	m := keywordMap{
+		exact:  make(map[string]*style.Highlight),
+		folded: make(map[string]*style.Highlight),
+	}
+	for _, syntax := range syntaxList {
+		for _, keyword := range syntax.Keywords {
+			hl, ok := highlights[syntax.Group]
+			if !ok {
+				return m, fmt.Errorf("highlight group %q not found", syntax.Group)
+			}
+			if syntax.IgnoreCase {
+				m.folded[strings.ToLower(keyword)] = hl
+			} else {
+				m.exact[keyword] = hl
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m keywordMap) lookup(word string) (*style.Highlight, bool) {
+	if hl, ok := m.exact[word]; ok {
+		return hl, true
+	}
+	if len(m.folded) == 0 {
+		return nil, false
+	}
+	hl, ok := m.folded[strings.ToLower(word)]
+	return hl, ok
+}
+
+func (m keywordMap) empty() bool {
+	return len(m.exact) == 0 && len(m.folded) == 0
+}
+
 func findKeywordMatches(
 	matches *MatchLayer,
-	keywordMap map[string]*style.Highlight,
-	text string,
+	keywordMap keywordMap,
+	l *line,
 ) error {
-	for _, idx := range unicodeWordRe.FindAllStringIndex(text, -1) {
+	if keywordMap.empty() {
+		return nil
+	}
+	for _, idx := range l.wordSpans() {
 		start, end := idx[0], idx[1]
-		word := text[start:end]
-		if hl, ok := keywordMap[word]; ok {
+		word := l.text[start:end]
+		if hl, ok := keywordMap.lookup(word); ok {
 			*matches = append(*matches, Match{
 				Start:     start,
 				End:       end,
@@ -1,6 +1,6 @@
 package renderer
 
-import "sort"
+import "slices"
 
 type MatchLayer []Match
 
@@ -9,38 +9,48 @@ func (matches MatchLayer) Len() int {
 }
 
 func (matches *MatchLayer) Sort() *MatchLayer {
-	sort.SliceStable(*matches, func(i, j int) bool {
-		a, b := (*matches)[i], (*matches)[j]
+	slices.SortStableFunc(*matches, func(a, b Match) int {
 		if a.Start == b.Start {
-			return a.End < b.End
+			return a.End - b.End
 		}
-		return a.Start < b.Start
+		return a.Start - b.Start
 	})
 	return matches
 }
 
 func (matches *MatchLayer) removeOverlaps() *MatchLayer {
-	var validMatches MatchLayer
+	// valid matches are moved to the end of the layer, in place
+	ms := *matches
+	valid := len(ms)
 	// NOTE: later matches have higher priority
-	for i := len(*matches) - 1; i >= 0; i-- {
-		match := (*matches)[i]
+	for i := len(ms) - 1; i >= 0; i-- {
+		match := ms[i]
 		collision := false
-		for _, existingMatch := range validMatches {
+		for _, existingMatch := range ms[valid:] {
 			if !(match.End <= existingMatch.Start || match.Start >= existingMatch.End) {
 				collision = true
 				break
 			}
 		}
 		if !collision {
-			validMatches = append(validMatches, match)
+			valid--
+			ms[valid] = match
 		}
 	}
-	*matches = validMatches
+	n := copy(ms, ms[valid:])
+	// keep the highest priority first, as before
+	slices.Reverse(ms[:n])
+	*matches = ms[:n]
 	return matches
 }
 
 func Stack(top MatchLayer, bottom MatchLayer) MatchLayer {
-	out := MatchLayer{}
+	return stackInto(MatchLayer{}, top, bottom)
+}
+
+// stackInto appends the result of stacking top on bottom to out. Like Stack,
+// it modifies bottom.
+func stackInto(out MatchLayer, top MatchLayer, bottom MatchLayer) MatchLayer {
 	iTop, iBot := 0, 0
 
 	for iTop < len(top) && iBot < len(bottom) {
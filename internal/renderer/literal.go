@@ -0,0 +1,103 @@
+package renderer
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/madmaxieee/loglit/internal/ahocorasick"
+	"github.com/madmaxieee/loglit/internal/proto"
+	"github.com/madmaxieee/loglit/internal/style"
+)
+
+// literalSet is an automaton for a set of literals along with the syntax
+// options of each literal.
+type literalSet struct {
+	matcher    *ahocorasick.Matcher
+	groups     []string
+	highlights []*style.Highlight
+	wholeWord  []bool
+}
+
+// literalMatcher finds the literals of a syntax list in a single pass per
+// case sensitivity, no matter how many literals there are.
+type literalMatcher []literalSet
+
+func newLiteralMatcher(syntaxList []proto.Syntax, highlights map[string]*style.Highlight) (literalMatcher, error) {
+	var exact, folded struct {
+		literals []string
+		set      literalSet
+	}
+	for _, syn := range syntaxList {
+		if len(syn.Literals) == 0 {
+			continue
+		}
+		hl, ok := highlights[syn.Group]
+		if !ok {
+			return nil, fmt.Errorf("highlight group %q not found", syn.Group)
+		}
+		target := &exact
+		if syn.IgnoreCase {
+			target = &folded
+		}
+		for _, literal := range syn.Literals {
+			target.literals = append(target.literals, literal)
+			target.set.groups = append(target.set.groups, syn.Group)
+			target.set.highlights = append(target.set.highlights, hl)
+			target.set.wholeWord = append(target.set.wholeWord, syn.WholeWord)
+		}
+	}
+
+	var m literalMatcher
+	if len(exact.literals) > 0 {
+		exact.set.matcher = ahocorasick.New(exact.literals, false)
+		m = append(m, exact.set)
+	}
+	if len(folded.literals) > 0 {
+		folded.set.matcher = ahocorasick.New(folded.literals, true)
+		m = append(m, folded.set)
+	}
+	return m, nil
+}
+
+func (set literalSet) find(buf *ahocorasick.Buffer, text string) []ahocorasick.Match {
+	return set.matcher.FindAll(buf, text, func(match ahocorasick.Match) bool {
+		return !set.wholeWord[match.Pattern] || isWholeWord(text, match.Start, match.End)
+	})
+}
+
+func findLiteralMatches(s *scratch, matches *MatchLayer, literals literalMatcher, rb *rainbow, text string) {
+	for _, set := range literals {
+		for _, match := range set.find(&s.literals, text) {
+			group, hl := set.groups[match.Pattern], set.highlights[match.Pattern]
+			*matches = append(*matches, patternMatch(group, hl, rb, text, match.Start, match.End))
+		}
+	}
+}
+
+// matchesAny reports whether any literal occurs in text.
+func (m literalMatcher) matchesAny(s *scratch, text string) bool {
+	for _, set := range m {
+		if len(set.find(&s.literals, text)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// isWholeWord reports whether text[start:end] is not surrounded by word
+// characters, using the same definition of words as keywords.
+func isWholeWord(text string, start, end int) bool {
+	if start > 0 {
+		r, _ := utf8.DecodeLastRuneInString(text[:start])
+		if proto.IsWordRune(r) {
+			return false
+		}
+	}
+	if end < len(text) {
+		r, _ := utf8.DecodeRuneInString(text[end:])
+		if proto.IsWordRune(r) {
+			return false
+		}
+	}
+	return true
+}
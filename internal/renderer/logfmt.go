@@ -0,0 +1,82 @@
+package renderer
+
+import (
+	"regexp"
+
+	"github.com/madmaxieee/loglit/internal/config"
+	"github.com/madmaxieee/loglit/internal/style"
+)
+
+// logfmtPairRe matches key=value pairs, where the key starts the line or
+// follows a separator and the value is either quoted or runs until the next
+// whitespace.
+var logfmtPairRe = regexp.MustCompile(`(?:^|[\s,;|\[(])([A-Za-z_][\w.\-/@]*)=("(?:[^"\\]|\\.)*"|[^\s"]*)`)
+
+var logfmtLevelKeys = map[string]bool{
+	"level":    true,
+	"lvl":      true,
+	"severity": true,
+}
+
+type logfmtHighlights struct {
+	key   *style.Highlight
+	value *style.Highlight
+	// highlights for values of specific keys
+	keyValues map[string]*style.Highlight
+}
+
+func newLogfmtHighlights(cfg config.LogfmtConfig, highlights map[string]*style.Highlight) (*logfmtHighlights, error) {
+	hls := &logfmtHighlights{keyValues: make(map[string]*style.Highlight)}
+	var err error
+	hls.key, err = getHighlight(highlights, "LogfmtKey")
+	if err != nil {
+		return nil, err
+	}
+	hls.value, err = getHighlight(highlights, "LogfmtValue")
+	if err != nil {
+		return nil, err
+	}
+	for key, group := range cfg.KeyGroups {
+		hls.keyValues[key], err = getHighlight(highlights, group)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return hls, nil
+}
+
+// findLogfmtMatches finds the key=value pairs in text. It returns two layers:
+// values, meant to go under the built-in syntax so numbers or durations keep
+// their colors, and keys along with the values of level keys and keys with
+// a configured style, meant to go above it.
+func (r Renderer) findLogfmtMatches(s *scratch, text string) (MatchLayer, MatchLayer) {
+	hls := r.logfmtHighlights
+	values, keys := s.layer(), s.layer()
+	for _, idx := range logfmtPairRe.FindAllStringSubmatchIndex(text, -1) {
+		keyStart, keyEnd := idx[2], idx[3]
+		valueStart, valueEnd := idx[4], idx[5]
+		key := text[keyStart:keyEnd]
+
+		*keys = append(*keys, newMatch(keyStart, keyEnd, hls.key))
+		if valueStart == valueEnd {
+			continue
+		}
+
+		if hl, ok := hls.keyValues[key]; ok {
+			*keys = append(*keys, newMatch(valueStart, valueEnd, hl))
+			continue
+		}
+		if logfmtLevelKeys[key] {
+			value := text[valueStart:valueEnd]
+			if len(value) >= 2 && value[0] == '"' {
+				value = value[1 : len(value)-1]
+			}
+			if hl, ok := r.builtin.keywords.lookup(value); ok {
+				*keys = append(*keys, newMatch(valueStart, valueEnd, hl))
+				continue
+			}
+		}
+		*values = append(*values, newMatch(valueStart, valueEnd, hls.value))
+	}
+	return *values, *keys
+}
@@ -0,0 +1,115 @@
+package renderer
+
+import (
+	"github.com/madmaxieee/loglit/internal/proto"
+	"github.com/madmaxieee/loglit/internal/style"
+)
+
+// syntaxMatcher finds the matches of a syntax list. Patterns are skipped on
+// lines their prefilter rules out and fixed-width patterns are matched
+// without the regex engine. Keywords are looked up in a map for each word of
+// the line and literals are found in a single pass. The highlights of capture
+// groups are resolved when it is built.
+//
+// The patterns are not combined into a single regex: an alternation only
+// finds the leftmost match at each position, so an earlier pattern matching
+// first would hide an overlapping match of a later one, which has priority
+// once all matches are found.
+type syntaxMatcher struct {
+	syntax     []proto.Syntax
+	prefilters []prefilter
+	fixed      []fixedPattern
+	captures   []*patternCaptures
+	keywords   keywordMap
+	literals   literalMatcher
+}
+
+func newSyntaxMatcher(syntaxList []proto.Syntax, highlights map[string]*style.Highlight) (*syntaxMatcher, error) {
+	m := &syntaxMatcher{
+		// apply pattern options once instead of on every line
+		syntax:     applySyntaxOptions(syntaxList),
+		prefilters: make([]prefilter, len(syntaxList)),
+		fixed:      make([]fixedPattern, len(syntaxList)),
+		captures:   make([]*patternCaptures, len(syntaxList)),
+	}
+	for i, syn := range m.syntax {
+		if !syn.Pattern.HasValue() {
+			if err := syn.ValidateCaptures(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		m.prefilters[i] = newPrefilter(syn.Pattern.String())
+		if len(syn.Captures) > 0 {
+			var err error
+			m.captures[i], err = newPatternCaptures(syn, highlights)
+			if err != nil {
+				return nil, err
+			}
+		}
+		// the regex of whole words matches more, which the pattern filters
+		if len(syn.Captures) == 0 && !syn.Pattern.IsWholeWord() {
+			m.fixed[i] = compileFixed(syn.Pattern.String())
+		}
+	}
+
+	var err error
+	m.keywords, err = newKeywordMap(syntaxList, highlights)
+	if err != nil {
+		return nil, err
+	}
+	m.literals, err = newLiteralMatcher(syntaxList, highlights)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// find returns the non-overlapping matches in l. Literals take priority over
+// keywords, which take priority over patterns, and later patterns take
+// priority over earlier ones.
+func (m *syntaxMatcher) find(s *scratch, highlights map[string]*style.Highlight, rb *rainbow, l *line) (MatchLayer, error) {
+	matches := s.layer()
+
+	err := m.findPatternMatches(matches, highlights, rb, l)
+	if err != nil {
+		return nil, err
+	}
+	err = findKeywordMatches(matches, m.keywords, l)
+	if err != nil {
+		return nil, err
+	}
+	findLiteralMatches(s, matches, m.literals, rb, l.text)
+
+	matches.removeOverlaps()
+	matches.Sort()
+
+	return *matches, nil
+}
+
+// matchesAny reports whether any pattern, literal or keyword matches l.
+func (m *syntaxMatcher) matchesAny(s *scratch, l *line) bool {
+	for i, syn := range m.syntax {
+		if !syn.Pattern.HasValue() || !m.prefilters[i].mayMatch(l) {
+			continue
+		}
+		if m.fixed[i] != nil {
+			if _, _, ok := m.fixed[i].next(l.text, 0); ok {
+				return true
+			}
+		} else if syn.Pattern.MatchString(l.text) {
+			return true
+		}
+	}
+	if m.literals.matchesAny(s, l.text) {
+		return true
+	}
+	if !m.keywords.empty() {
+		for _, idx := range l.wordSpans() {
+			if _, ok := m.keywords.lookup(l.text[idx[0]:idx[1]]); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
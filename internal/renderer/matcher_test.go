@@ -0,0 +1,138 @@
+package renderer
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/madmaxieee/loglit/internal/config"
+	"github.com/madmaxieee/loglit/internal/proto"
+)
+
+var matcherTestLines = []string{
+	"2023-10-27 10:00:00 INFO [main] This is a test log message with some numbers 12345 and a url https://example.com",
+	"2024-05-01T12:01:02.123Z ERROR request 123e4567-e89b-12d3-a456-426614174000 failed after 1h2m3.5s",
+	"Oct 27 10:00:00 host sshd[42]: Accepted publickey for root from 10.0.0.1/24 port 22",
+	"27/10/2023 00:11:22:33:44:55 fe80:0:0:0:0:0:0:1 0x1F 0b101 0o17 017 3.14e-10",
+	"d41d8cd98f00b204e9800998ecf8427e da39a3ee5e6b4b0d3255bfef95601890afd80709",
+	`level=warn msg="disk \"almost\" full" path=/var/log/syslog ratio=0.93 ok=true nil`,
+	`{"time":"2024-05-01T12:01:02Z","level":"error","msg":"boom","n":null}`,
+	"MY_APP_WARNING: ---- ==== #### ***  <<< >>> !@#$%^&*;:?= \\n \\t 'quoted' \"double\"",
+	"2023/10/27 10-27 10/27 27-Oct-2023 27 Oct 2023 12:00:00,123456 500µs 10ns",
+	"naïve café ünïcödé 42 ÄÖÜ_ERROR \xff\xfe invalid 0xZZ _0x10 a0x10",
+	"",
+}
+
+// randomLines returns lines of characters that are likely to form partial
+// matches of the built-in syntax.
+func randomLines(n int) []string {
+	const alphabet = "0123456789abcdefxABCDEFZT-:./ _,+sµhmd\"'=[]\xff"
+	rng := rand.New(rand.NewPCG(1, 2))
+	lines := make([]string, n)
+	for i := range lines {
+		b := make([]byte, rng.IntN(80))
+		for j := range b {
+			b[j] = alphabet[rng.IntN(len(alphabet))]
+		}
+		lines[i] = string(b)
+	}
+	return lines
+}
+
+// regexpOnly returns a copy of m that runs the regex of every pattern.
+func regexpOnly(m *syntaxMatcher) *syntaxMatcher {
+	naive := *m
+	naive.prefilters = make([]prefilter, len(m.syntax))
+	naive.fixed = make([]fixedPattern, len(m.syntax))
+	return &naive
+}
+
+func TestSyntaxMatcherMatchesRegexp(t *testing.T) {
+	r := newTestRenderer(t, config.GetDefaultConfig())
+
+	lines := append(randomLines(2000), matcherTestLines...)
+	for _, m := range []*syntaxMatcher{r.builtinLower, r.builtin} {
+		naive := regexpOnly(m)
+		for _, text := range lines {
+			got, err := m.find(new(scratch), r.Theme.HighlightMap, nil, newLine(text))
+			if err != nil {
+				t.Fatalf("find failed: %v", err)
+			}
+			expected, err := naive.find(new(scratch), r.Theme.HighlightMap, nil, newLine(text))
+			if err != nil {
+				t.Fatalf("find failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("matches differ for %q:\ngot      %+v\nexpected %+v", text, got, expected)
+			}
+		}
+	}
+}
+
+func TestPrefilterFoldCase(t *testing.T) {
+	tests := []struct {
+		expr string
+		text string
+	}{
+		{`(?i)key`, "\u212Aey"},
+		{`(?i)session`, "\u017Fession"},
+		{`x(?i:k)y`, "x\u212Ay"},
+		{`(?i)key`, "KEY"},
+	}
+	for _, tt := range tests {
+		expected := regexp.MustCompile(tt.expr).MatchString(tt.text)
+		if got := newPrefilter(tt.expr).mayMatch(newLine(tt.text)); got != expected {
+			t.Errorf("prefilter of %q on %q: got %v, expected %v", tt.expr, tt.text, got, expected)
+		}
+	}
+}
+
+func TestPrefilterAndFixedPatterns(t *testing.T) {
+	cfg := config.GetDefaultConfig()
+	syntaxList := append(cfg.BuiltInSyntaxLower, cfg.BuiltInSyntax...)
+	syntaxList = append(syntaxList,
+		proto.Syntax{Pattern: proto.MustCompile(`(?i)\bwarn(ing)?\b`)},
+		proto.Syntax{Pattern: proto.MustCompile(`(?i)k8s-[a-z]{3}`)},
+		proto.Syntax{Pattern: proto.MustCompile(`^\d{2}\B\d`)},
+		proto.Syntax{Pattern: proto.MustCompile(`é\d|\d{3}$`)},
+	)
+
+	lines := append(randomLines(2000), matcherTestLines...)
+	lines = append(lines, "WARNING Warn k8s-ABC K8S-abc \u212a8s-abc 123 é4")
+	fixedCount := 0
+	for _, syn := range syntaxList {
+		if !syn.Pattern.HasValue() {
+			continue
+		}
+		expr := syn.Pattern.String()
+		pf := newPrefilter(expr)
+		fixed := compileFixed(expr)
+		if fixed != nil {
+			fixedCount++
+		}
+		for _, text := range lines {
+			expected := syn.Pattern.FindAllStringIndex(text, -1)
+			if len(expected) > 0 && !pf.mayMatch(newLine(text)) {
+				t.Errorf("prefilter of %q rejects matching line %q", expr, text)
+			}
+			if fixed == nil {
+				continue
+			}
+			var got [][]int
+			for _, span := range fixed.findAll(text) {
+				got = append(got, []int{span[0], span[1]})
+			}
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("fixed pattern %q on %q: got %v, expected %v", expr, text, got, expected)
+			}
+		}
+	}
+	if fixedCount == 0 {
+		t.Error("expected some built-in patterns to be fixed-width")
+	}
+
+	if compileFixed(`\d+`) != nil || compileFixed(`a|bc`) != nil || compileFixed(`\b`) != nil {
+		t.Error("expected variable-width patterns not to be fixed")
+	}
+}
@@ -2,35 +2,139 @@ package renderer
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/madmaxieee/loglit/internal/proto"
 	"github.com/madmaxieee/loglit/internal/style"
 )
 
-func findPatternMatches(
+// applySyntaxOptions returns syntaxList with the IgnoreCase and WholeWord
+// options compiled into the patterns.
+func applySyntaxOptions(syntaxList []proto.Syntax) []proto.Syntax {
+	if !slices.ContainsFunc(syntaxList, func(syn proto.Syntax) bool {
+		return syn.Pattern.HasValue() && (syn.IgnoreCase || syn.WholeWord)
+	}) {
+		return syntaxList
+	}
+	result := slices.Clone(syntaxList)
+	for i, syn := range result {
+		if syn.Pattern.HasValue() {
+			result[i].Pattern = syn.Pattern.WithOptions(syn.IgnoreCase, syn.WholeWord)
+		}
+	}
+	return result
+}
+
+func (m *syntaxMatcher) findPatternMatches(
 	matches *MatchLayer,
-	syntaxList []proto.Syntax,
 	highlights map[string]*style.Highlight,
-	text string,
+	rb *rainbow,
+	l *line,
 ) error {
-	for _, syn := range syntaxList {
+	text := l.text
+	for i, syn := range m.syntax {
 		p := syn.Pattern
-		if !p.HasValue() {
+		if !p.HasValue() || !m.prefilters[i].mayMatch(l) {
+			continue
+		}
+		if c := m.captures[i]; c != nil {
+			*matches = append(*matches, c.findMatches(p, rb, text)...)
 			continue
 		}
 		hl, ok := highlights[syn.Group]
 		if !ok {
 			return fmt.Errorf("highlight group %s not found", syn.Group)
 		}
+		if fixed := m.fixed[i]; fixed != nil {
+			for start, end, ok := fixed.next(text, 0); ok; start, end, ok = fixed.next(text, end) {
+				*matches = append(*matches, patternMatch(syn.Group, hl, rb, text, start, end))
+			}
+			continue
+		}
 		for _, idx := range p.FindAllStringIndex(text, -1) {
-			*matches = append(*matches, Match{
-				Start:     idx[0],
-				End:       idx[1],
-				AnsiStart: hl.BuildAnsi(),
-				AnsiEnd:   hl.BuildAnsiReset(),
-			})
+			*matches = append(*matches, patternMatch(syn.Group, hl, rb, text, idx[0], idx[1]))
 		}
 	}
 
 	return nil
 }
+
+// captureHighlight is a capture group of a pattern with the highlight of
+// its text.
+type captureHighlight struct {
+	index int
+	group string
+	hl    *style.Highlight
+}
+
+// patternCaptures holds the highlights of the capture groups of a syntax,
+// resolved once when the matcher is built.
+type patternCaptures struct {
+	group string
+	// highlight of the whole match, nil to leave it unstyled
+	hl *style.Highlight
+	// sorted by index, so that nested groups come after the groups
+	// containing them and are stacked on top of them
+	captures []captureHighlight
+}
+
+// newPatternCaptures resolves the capture groups of syn and their highlights,
+// failing on names that are not in the pattern or the theme.
+func newPatternCaptures(syn proto.Syntax, highlights map[string]*style.Highlight) (*patternCaptures, error) {
+	c := &patternCaptures{group: syn.Group}
+	if syn.Group != "" {
+		var ok bool
+		c.hl, ok = highlights[syn.Group]
+		if !ok {
+			return nil, fmt.Errorf("highlight group %s not found", syn.Group)
+		}
+	}
+	for key, group := range syn.Captures {
+		index := syn.Pattern.CaptureIndex(key)
+		if index < 0 {
+			return nil, fmt.Errorf("pattern %q has no capture group %q", syn.Pattern.String(), key)
+		}
+		hl, ok := highlights[group]
+		if !ok {
+			return nil, fmt.Errorf("highlight group %s not found", group)
+		}
+		c.captures = append(c.captures, captureHighlight{index: index, group: group, hl: hl})
+	}
+	slices.SortFunc(c.captures, func(a, b captureHighlight) int { return a.index - b.index })
+	return c, nil
+}
+
+// findMatches highlights each capture group of the matches of p with its own
+// group, on top of the highlight of the whole match.
+func (c *patternCaptures) findMatches(p proto.Pattern, rb *rainbow, text string) MatchLayer {
+	var matches MatchLayer
+	for _, idx := range p.FindAllStringSubmatchIndex(text, -1) {
+		var layer MatchLayer
+		if c.hl != nil {
+			layer = MatchLayer{patternMatch(c.group, c.hl, rb, text, idx[0], idx[1])}
+		}
+		for _, capture := range c.captures {
+			start, end := idx[2*capture.index], idx[2*capture.index+1]
+			if start < 0 || start == end {
+				continue
+			}
+			layer = Stack(MatchLayer{patternMatch(capture.group, capture.hl, rb, text, start, end)}, layer)
+		}
+		matches = append(matches, layer...)
+	}
+	return matches
+}
+
+// patternMatch styles text[start:end] with hl, or with its rainbow color if
+// group is a rainbow group.
+func patternMatch(group string, hl *style.Highlight, rb *rainbow, text string, start, end int) Match {
+	if rainbowHl, ok := rb.highlight(group, text[start:end]); ok {
+		hl = rainbowHl
+	}
+	return Match{
+		Start:     start,
+		End:       end,
+		AnsiStart: hl.BuildAnsi(),
+		AnsiEnd:   hl.BuildAnsiReset(),
+	}
+}
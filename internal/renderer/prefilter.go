@@ -0,0 +1,407 @@
+package renderer
+
+import (
+	"math/bits"
+	"regexp/syntax"
+	"slices"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/madmaxieee/loglit/internal/proto"
+)
+
+// byteSet is a set of bytes.
+type byteSet [4]uint64
+
+func (s *byteSet) add(b byte) {
+	s[b>>6] |= 1 << (b & 63)
+}
+
+func (s *byteSet) addRange(lo, hi byte) {
+	for b := int(lo); b <= int(hi); b++ {
+		s.add(byte(b))
+	}
+}
+
+func (s *byteSet) union(other byteSet) {
+	for i := range s {
+		s[i] |= other[i]
+	}
+}
+
+func (s byteSet) has(b byte) bool {
+	return s[b>>6]&(1<<(b&63)) != 0
+}
+
+func (s byteSet) intersects(other byteSet) bool {
+	return s[0]&other[0] != 0 || s[1]&other[1] != 0 || s[2]&other[2] != 0 || s[3]&other[3] != 0
+}
+
+func (s byteSet) len() int {
+	return bits.OnesCount64(s[0]) + bits.OnesCount64(s[1]) + bits.OnesCount64(s[2]) + bits.OnesCount64(s[3])
+}
+
+// addRune adds the first byte of the UTF-8 encoding of r. Invalid UTF-8
+// decodes as utf8.RuneError, so it may come from any non-ASCII byte.
+func (s *byteSet) addRune(r rune) {
+	if r == utf8.RuneError {
+		s.addRange(utf8.RuneSelf, 0xFF)
+		return
+	}
+	var buf [utf8.UTFMax]byte
+	utf8.EncodeRune(buf[:], r)
+	s.add(buf[0])
+}
+
+// addRuneRange adds the first bytes of all runes in [lo, hi].
+func (s *byteSet) addRuneRange(lo, hi rune) {
+	if lo < utf8.RuneSelf {
+		s.addRange(byte(lo), byte(min(hi, utf8.RuneSelf-1)))
+	}
+	if hi >= utf8.RuneSelf {
+		// any non-ASCII byte, being exact is not worth it and invalid UTF-8
+		// may match as utf8.RuneError
+		s.addRange(utf8.RuneSelf, 0xFF)
+	}
+}
+
+const (
+	// sets with more bytes than this rule out too few lines to be checked
+	maxPrefilterSetLen = 128
+	// only the most selective sets of a pattern are checked
+	maxPrefilterSets = 4
+	// longest run of adjacent bytes checked
+	maxPrefilterSeqLen = 8
+)
+
+// prefilter rules out lines a pattern cannot match without running the
+// regex. Every match of the pattern contains a byte of each of sets, and a
+// run of adjacent bytes each in the corresponding set of seq.
+type prefilter struct {
+	sets []byteSet
+	seq  []byteSet
+}
+
+func newPrefilter(expr string) prefilter {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return prefilter{}
+	}
+	re = re.Simplify()
+
+	sets := requiredSets(re)
+	sets = slices.DeleteFunc(sets, func(s byteSet) bool {
+		return s.len() > maxPrefilterSetLen
+	})
+	slices.SortStableFunc(sets, func(a, b byteSet) int {
+		return a.len() - b.len()
+	})
+	if len(sets) > maxPrefilterSets {
+		sets = sets[:maxPrefilterSets]
+	}
+
+	seq := analyzeSeq(re).longest()
+	// a single set is already covered by sets
+	if len(seq) < 2 {
+		seq = nil
+	}
+	if len(seq) > maxPrefilterSeqLen {
+		seq = seq[:maxPrefilterSeqLen]
+	}
+	return prefilter{sets: sets, seq: seq}
+}
+
+// mayMatch reports whether l may contain a match.
+func (p prefilter) mayMatch(l *line) bool {
+	for _, s := range p.sets {
+		if !s.intersects(l.present) {
+			return false
+		}
+	}
+	return len(p.seq) == 0 || containsSeq(l.text, p.seq)
+}
+
+// containsSeq reports whether text has a run of bytes each in the
+// corresponding set of seq.
+func containsSeq(text string, seq []byteSet) bool {
+	for i := 0; i+len(seq) <= len(text); i++ {
+		j := 0
+		for j < len(seq) && seq[j].has(text[i+j]) {
+			j++
+		}
+		if j == len(seq) {
+			return true
+		}
+	}
+	return false
+}
+
+// seqInfo describes runs of adjacent bytes required by a regex. If exact is
+// true every match is exactly full, otherwise matches start with prefix, end
+// with suffix and contain inner somewhere.
+type seqInfo struct {
+	exact  bool
+	full   []byteSet
+	prefix []byteSet
+	suffix []byteSet
+	inner  []byteSet
+}
+
+func exactSeq(seq []byteSet) seqInfo {
+	return seqInfo{exact: true, full: seq}
+}
+
+func (info seqInfo) starts() []byteSet {
+	if info.exact {
+		return info.full
+	}
+	return info.prefix
+}
+
+func (info seqInfo) ends() []byteSet {
+	if info.exact {
+		return info.full
+	}
+	return info.suffix
+}
+
+// longest returns the longest run every match contains.
+func (info seqInfo) longest() []byteSet {
+	if info.exact {
+		return info.full
+	}
+	return longestSeq(info.prefix, info.suffix, info.inner)
+}
+
+func longestSeq(seqs ...[]byteSet) []byteSet {
+	var best []byteSet
+	for _, seq := range seqs {
+		if len(seq) > len(best) {
+			best = seq
+		}
+	}
+	return best
+}
+
+func concatSeq(a, b []byteSet) []byteSet {
+	return append(slices.Clip(a), b...)
+}
+
+func analyzeSeq(re *syntax.Regexp) seqInfo {
+	switch re.Op {
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine,
+		syntax.OpBeginText, syntax.OpEndText, syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		// zero width, so the bytes around it are still adjacent
+		return exactSeq(nil)
+
+	case syntax.OpLiteral:
+		var seq []byteSet
+		for _, r := range re.Rune {
+			if r >= utf8.RuneSelf {
+				// folded non-ASCII runes may differ in length, give up on
+				// exactness instead of handling them
+				return seqInfo{prefix: seq}
+			}
+			var s byteSet
+			s.add(byte(r))
+			if re.Flags&syntax.FoldCase != 0 {
+				for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+					if f >= utf8.RuneSelf {
+						// such as the Kelvin sign for k, it is longer than
+						// a single byte
+						return seqInfo{prefix: seq}
+					}
+					s.add(byte(f))
+				}
+			}
+			seq = append(seq, s)
+		}
+		return exactSeq(seq)
+
+	case syntax.OpCharClass:
+		var s byteSet
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			if re.Rune[i+1] >= utf8.RuneSelf {
+				// non-ASCII runes have varying lengths
+				return seqInfo{}
+			}
+			s.addRange(byte(re.Rune[i]), byte(re.Rune[i+1]))
+		}
+		return exactSeq([]byteSet{s})
+
+	case syntax.OpCapture:
+		return analyzeSeq(re.Sub[0])
+
+	case syntax.OpPlus:
+		sub := analyzeSeq(re.Sub[0])
+		return seqInfo{prefix: sub.starts(), suffix: sub.ends(), inner: sub.longest()}
+
+	case syntax.OpConcat:
+		acc := exactSeq(nil)
+		for _, sub := range re.Sub {
+			info := analyzeSeq(sub)
+			if acc.exact && info.exact {
+				acc.full = concatSeq(acc.full, info.full)
+				continue
+			}
+			next := seqInfo{
+				prefix: acc.starts(),
+				suffix: info.ends(),
+				inner:  longestSeq(acc.longest(), info.longest(), concatSeq(acc.ends(), info.starts())),
+			}
+			if acc.exact {
+				next.prefix = concatSeq(acc.full, info.prefix)
+			}
+			if info.exact {
+				next.suffix = concatSeq(acc.suffix, info.full)
+			}
+			acc = next
+		}
+		return acc
+
+	case syntax.OpAlternate:
+		// position-wise unions of what every alternative starts and ends with
+		infos := make([]seqInfo, len(re.Sub))
+		for i, sub := range re.Sub {
+			infos[i] = analyzeSeq(sub)
+		}
+		prefix := slices.Clone(infos[0].starts())
+		suffix := slices.Clone(infos[0].ends())
+		exact := infos[0].exact
+		for _, info := range infos[1:] {
+			starts, ends := info.starts(), info.ends()
+			exact = exact && info.exact && len(starts) == len(prefix)
+			prefix = prefix[:min(len(prefix), len(starts))]
+			for i := range prefix {
+				prefix[i].union(starts[i])
+			}
+			suffix = suffix[len(suffix)-min(len(suffix), len(ends)):]
+			for i := range suffix {
+				suffix[i].union(ends[len(ends)-len(suffix)+i])
+			}
+		}
+		if exact {
+			return exactSeq(prefix)
+		}
+		return seqInfo{prefix: prefix, suffix: suffix}
+	}
+	// any char, star, quest and optional repeats
+	return seqInfo{}
+}
+
+// requiredSets returns byte sets such that every match of re contains a byte
+// of each set.
+func requiredSets(re *syntax.Regexp) []byteSet {
+	switch re.Op {
+	case syntax.OpLiteral:
+		sets := make([]byteSet, 0, len(re.Rune))
+		for _, r := range re.Rune {
+			var s byteSet
+			s.addRune(r)
+			if re.Flags&syntax.FoldCase != 0 {
+				for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+					s.addRune(f)
+				}
+			}
+			sets = append(sets, s)
+		}
+		return sets
+
+	case syntax.OpCharClass:
+		var s byteSet
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			s.addRuneRange(re.Rune[i], re.Rune[i+1])
+		}
+		return []byteSet{s}
+
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiredSets(re.Sub[0])
+
+	case syntax.OpRepeat:
+		if re.Min == 0 {
+			return nil
+		}
+		return requiredSets(re.Sub[0])
+
+	case syntax.OpConcat:
+		var sets []byteSet
+		for _, sub := range re.Sub {
+			sets = append(sets, requiredSets(sub)...)
+		}
+		return sets
+
+	case syntax.OpAlternate:
+		// a match contains a byte of the most selective set of whichever
+		// alternative matched
+		var s byteSet
+		for _, sub := range re.Sub {
+			subSets := requiredSets(sub)
+			if len(subSets) == 0 {
+				return nil
+			}
+			best := subSets[0]
+			for _, subSet := range subSets[1:] {
+				if subSet.len() < best.len() {
+					best = subSet
+				}
+			}
+			s.union(best)
+		}
+		return []byteSet{s}
+	}
+	// empty-width assertions, any char, star and quest require nothing
+	return nil
+}
+
+// line is a line being rendered, with information shared by all syntax lists
+// computed in a single pass.
+type line struct {
+	text    string
+	present byteSet
+	words   [][2]int
+	// whether words has been computed
+	split bool
+}
+
+func newLine(text string) *line {
+	l := &line{}
+	l.reset(text)
+	return l
+}
+
+// reset makes l hold text, keeping its buffers.
+func (l *line) reset(text string) {
+	l.text = text
+	l.present = byteSet{}
+	for i := 0; i < len(text); i++ {
+		l.present.add(text[i])
+	}
+	l.words = l.words[:0]
+	l.split = false
+}
+
+// wordSpans returns the spans of the words in the line, matching
+// unicodeWordRe.
+func (l *line) wordSpans() [][2]int {
+	if l.split {
+		return l.words
+	}
+	l.split = true
+	start := -1
+	for i, r := range l.text {
+		if proto.IsWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			l.words = append(l.words, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		l.words = append(l.words, [2]int{start, len(l.text)})
+	}
+	return l.words
+}
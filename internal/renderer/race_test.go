@@ -0,0 +1,7 @@
+//go:build race
+
+package renderer
+
+// raceEnabled is set when testing with the race detector, which makes
+// sync.Pool drop items and so allocate.
+const raceEnabled = true
@@ -0,0 +1,43 @@
+package renderer
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"github.com/madmaxieee/loglit/internal/style"
+)
+
+// rainbow colors the matches of selected groups by hashing the matched text,
+// so every occurrence of the same identifier gets the same color and
+// different identifiers most likely get different ones.
+type rainbow struct {
+	groups  map[string]bool
+	palette []*style.Highlight
+}
+
+func newRainbow(groups []string, palette []*style.Highlight) (*rainbow, error) {
+	if len(groups) == 0 {
+		return nil, nil
+	}
+	if len(palette) == 0 {
+		return nil, fmt.Errorf("theme defines no LogRainbow colors for rainbow groups")
+	}
+	rb := &rainbow{
+		groups:  make(map[string]bool, len(groups)),
+		palette: palette,
+	}
+	for _, group := range groups {
+		rb.groups[group] = true
+	}
+	return rb, nil
+}
+
+// highlight returns the palette color for text if group is colored by hash.
+func (rb *rainbow) highlight(group string, text string) (*style.Highlight, bool) {
+	if rb == nil || !rb.groups[group] {
+		return nil, false
+	}
+	h := fnv.New32a()
+	h.Write([]byte(text))
+	return rb.palette[h.Sum32()%uint32(len(rb.palette))], true
+}
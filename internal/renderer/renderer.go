@@ -2,23 +2,27 @@ package renderer
 
 import (
 	"fmt"
+	"io"
 	"strings"
+	"unsafe"
 
 	"github.com/madmaxieee/loglit/internal/config"
-	"github.com/madmaxieee/loglit/internal/proto"
 	"github.com/madmaxieee/loglit/internal/style"
 	"github.com/madmaxieee/loglit/internal/theme"
 )
 
-type keywordMap map[string]*style.Highlight
-
 type Renderer struct {
 	Config config.Config
 	Theme  theme.Theme
-
-	builtinLowerKeywordMap keywordMap
-	builtinKeywordMap      keywordMap
-	userKeywordMap         keywordMap
+	// NoColor makes Render return lines unchanged.
+	NoColor bool
+
+	builtinLower     *syntaxMatcher
+	builtin          *syntaxMatcher
+	user             *syntaxMatcher
+	jsonHighlights   *jsonHighlights
+	logfmtHighlights *logfmtHighlights
+	rainbow          *rainbow
 }
 
 func New(cfg config.Config, th theme.Theme) (*Renderer, error) {
@@ -26,6 +30,13 @@ func New(cfg config.Config, th theme.Theme) (*Renderer, error) {
 		Config: cfg,
 		Theme:  th,
 	}
+	// the built-in links are defaults for groups the theme does not style
+	// itself, the highlights of the user override both
+	for _, hl := range cfg.BuiltInHighlight {
+		if _, ok := th.HighlightMap[hl.Group]; !ok {
+			th.Insert(hl)
+		}
+	}
 	for _, hl := range cfg.Highlight {
 		th.Insert(hl)
 	}
@@ -34,43 +45,51 @@ func New(cfg config.Config, th theme.Theme) (*Renderer, error) {
 		return nil, err
 	}
 
-	// precompile keyword map
-	renderer.builtinLowerKeywordMap = make(keywordMap)
-	for _, syntax := range renderer.Config.BuiltInSyntaxLower {
-		for _, keyword := range syntax.Keywords {
-			hl, ok := th.HighlightMap[syntax.Group]
-			if !ok {
-				return nil, fmt.Errorf("highlight group %q not found", syntax.Group)
-			}
-			renderer.builtinLowerKeywordMap[keyword] = hl
-		}
+	renderer.builtinLower, err = newSyntaxMatcher(cfg.BuiltInSyntaxLower, th.HighlightMap)
+	if err != nil {
+		return nil, err
+	}
+	renderer.builtin, err = newSyntaxMatcher(cfg.BuiltInSyntax, th.HighlightMap)
+	if err != nil {
+		return nil, err
+	}
+	renderer.user, err = newSyntaxMatcher(cfg.UserSyntax, th.HighlightMap)
+	if err != nil {
+		return nil, err
 	}
 
-	renderer.builtinKeywordMap = make(keywordMap)
-	for _, syntax := range renderer.Config.BuiltInSyntax {
-		for _, keyword := range syntax.Keywords {
-			hl, ok := th.HighlightMap[syntax.Group]
-			if !ok {
-				return nil, fmt.Errorf("highlight group %q not found", syntax.Group)
-			}
-			renderer.builtinKeywordMap[keyword] = hl
-		}
+	renderer.jsonHighlights, err = newJSONHighlights(th.HighlightMap)
+	if err != nil {
+		return nil, err
 	}
 
-	renderer.userKeywordMap = make(keywordMap)
-	for _, syntax := range renderer.Config.UserSyntax {
-		for _, keyword := range syntax.Keywords {
-			hl, ok := th.HighlightMap[syntax.Group]
-			if !ok {
-				return nil, fmt.Errorf("highlight group %q not found", syntax.Group)
-			}
-			renderer.userKeywordMap[keyword] = hl
-		}
+	renderer.logfmtHighlights, err = newLogfmtHighlights(cfg.Logfmt, th.HighlightMap)
+	if err != nil {
+		return nil, err
+	}
+
+	renderer.rainbow, err = newRainbow(cfg.Rainbow, th.Palette("LogRainbow"))
+	if err != nil {
+		return nil, err
+	}
+
+	// lines are rendered concurrently, so the escape sequences are built up
+	// front instead of lazily
+	for _, hl := range th.HighlightMap {
+		hl.CacheAnsi()
 	}
 
 	return renderer, nil
 }
 
+func getHighlight(highlights map[string]*style.Highlight, group string) (*style.Highlight, error) {
+	hl, ok := highlights[group]
+	if !ok {
+		return nil, fmt.Errorf("highlight group %q not found", group)
+	}
+	return hl, nil
+}
+
 type Match struct {
 	Start     int
 	End       int
@@ -78,40 +97,71 @@ type Match struct {
 	AnsiEnd   string
 }
 
+// Render returns text with highlighting applied.
 func (r Renderer) Render(text string) (string, error) {
-	builtInLowerMatches, err := findMatches(
-		r.Config.BuiltInSyntaxLower,
-		r.Theme.HighlightMap,
-		r.builtinLowerKeywordMap,
-		text,
-	)
-	if err != nil {
-		return text, err
+	if r.NoColor {
+		return text, nil
+	}
+	var b strings.Builder
+	b.Grow(len(text) * 2)
+	err := r.render(&b, text)
+	return b.String(), err
+}
+
+// RenderTo writes line with highlighting applied to w, without building the
+// result in memory. Once its reused buffers have grown to fit, it only
+// allocates for matches of regexes that the built-in engine doesn't handle.
+// line is neither modified nor retained.
+func (r Renderer) RenderTo(w io.Writer, line []byte) error {
+	// line is only read during the call, so it is viewed as a string instead
+	// of being copied
+	return r.render(w, unsafe.String(unsafe.SliceData(line), len(line)))
+}
+
+// render writes text with highlighting applied to w. If the matches cannot be
+// found, text is written unchanged and the error is returned.
+func (r Renderer) render(w io.Writer, text string) error {
+	if r.NoColor {
+		_, err := io.WriteString(w, text)
+		return err
 	}
 
-	builtInMatches, err := findMatches(
-		r.Config.BuiltInSyntax,
-		r.Theme.HighlightMap,
-		r.builtinKeywordMap,
-		text,
-	)
+	s := getScratch()
+	defer s.release()
+
+	matches, prefix, suffix, err := r.findMatches(s, text)
 	if err != nil {
-		return text, err
+		io.WriteString(w, text)
+		return err
 	}
+	return writeHighlighted(w, text, matches, prefix, suffix)
+}
 
-	builtinMatchesCombined := Stack(builtInMatches, builtInLowerMatches)
+// findMatches returns the sorted matches in text, along with the escape
+// sequences to write before and after the line.
+func (r Renderer) findMatches(s *scratch, text string) (MatchLayer, string, string, error) {
+	l := s.line(text)
+	builtinMatchesCombined, isJSON, err := r.findJSONMatches(s, text)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if !isJSON {
+		builtinMatchesCombined, err = r.findBuiltinMatches(s, l)
+		if err != nil {
+			return nil, "", "", err
+		}
+		if r.Config.Logfmt.Enabled {
+			values, keys := r.findLogfmtMatches(s, text)
+			builtinMatchesCombined = s.stack(keys, s.stack(builtinMatchesCombined, values))
+		}
+	}
 
-	userMatches, err := findMatches(
-		r.Config.UserSyntax,
-		r.Theme.HighlightMap,
-		r.userKeywordMap,
-		text,
-	)
+	userMatches, err := r.user.find(s, r.Theme.HighlightMap, r.rainbow, l)
 	if err != nil {
-		return text, err
+		return nil, "", "", err
 	}
 
-	matches := Stack(userMatches, builtinMatchesCombined)
+	matches := s.stack(userMatches, builtinMatchesCombined)
 
 	prefix := ""
 	suffix := ""
@@ -119,7 +169,7 @@ func (r Renderer) Render(text string) (string, error) {
 	if userMatches.Len() > 0 {
 		userBgHighlight, ok := r.Theme.HighlightMap["UserMatchLineBackground"]
 		if !ok {
-			return text, fmt.Errorf("highlight group %q not found", "UserMatchLineBackground")
+			return nil, "", "", fmt.Errorf("highlight group %q not found", "UserMatchLineBackground")
 		}
 
 		// If we have user matches, the default background color for the line
@@ -135,56 +185,65 @@ func (r Renderer) Render(text string) (string, error) {
 		suffix = userBgHighlight.BuildAnsiReset()
 	}
 
-	if len(matches) == 0 {
-		return text, nil
-	}
-
 	matches.Sort()
 
-	return prefix + buildHighlightedString(text, matches) + suffix, nil
+	return matches, prefix, suffix, nil
 }
 
-func findMatches(
-	syntaxList []proto.Syntax,
-	highlights map[string]*style.Highlight,
-	keywordMap map[string]*style.Highlight,
-	text string,
-) (MatchLayer, error) {
-	var matches MatchLayer
-	var err error
-
-	err = findPatternMatches(&matches, syntaxList, highlights, text)
+// findBuiltinMatches returns the matches of the built-in syntax in l.
+func (r Renderer) findBuiltinMatches(s *scratch, l *line) (MatchLayer, error) {
+	builtInLowerMatches, err := r.builtinLower.find(s, r.Theme.HighlightMap, r.rainbow, l)
 	if err != nil {
 		return nil, err
 	}
 
-	err = findKeywordMatches(&matches, keywordMap, text)
+	builtInMatches, err := r.builtin.find(s, r.Theme.HighlightMap, r.rainbow, l)
 	if err != nil {
 		return nil, err
 	}
 
-	matches.removeOverlaps()
-	matches.Sort()
+	return s.stack(builtInMatches, builtInLowerMatches), nil
+}
 
-	return matches, nil
+// MatchesUserSyntax reports whether any user pattern, keyword or literal
+// matches text.
+func (r Renderer) MatchesUserSyntax(text string) bool {
+	s := getScratch()
+	defer s.release()
+	return r.user.matchesAny(s, s.line(text))
 }
 
-func buildHighlightedString(text string, matches MatchLayer) string {
-	var b strings.Builder
-	b.Grow(len(text) * 2)
+// writeHighlighted writes text to w with the escape sequences of matches
+// around the matched parts. Lines without matches are written unchanged.
+func writeHighlighted(w io.Writer, text string, matches MatchLayer, prefix, suffix string) error {
+	if len(matches) == 0 {
+		_, err := io.WriteString(w, text)
+		return err
+	}
 
-	b.WriteString(text[:matches[0].Start])
-	for i, match := range matches {
-		b.WriteString(match.AnsiStart)
-		b.WriteString(text[match.Start:match.End])
-		b.WriteString(match.AnsiEnd)
-		if i == len(matches)-1 {
-			b.WriteString(text[match.End:])
-		} else {
-			nextMatch := matches[i+1]
-			b.WriteString(text[match.End:nextMatch.Start])
-		}
+	sw := stringWriter{w: w}
+	sw.write(prefix)
+	end := 0
+	for _, match := range matches {
+		sw.write(text[end:match.Start])
+		sw.write(match.AnsiStart)
+		sw.write(text[match.Start:match.End])
+		sw.write(match.AnsiEnd)
+		end = match.End
 	}
+	sw.write(text[end:])
+	sw.write(suffix)
+	return sw.err
+}
 
-	return b.String()
+// stringWriter writes strings to w until the first error.
+type stringWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (sw *stringWriter) write(s string) {
+	if sw.err == nil && s != "" {
+		_, sw.err = io.WriteString(sw.w, s)
+	}
 }
@@ -1,36 +1,88 @@
 package renderer
 
 import (
+	"bufio"
+	"io"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/madmaxieee/loglit/internal/config"
 	"github.com/madmaxieee/loglit/internal/proto"
+	"github.com/madmaxieee/loglit/internal/style"
 	"github.com/madmaxieee/loglit/internal/theme"
 )
 
+var benchmarkLines = []struct {
+	name string
+	line string
+}{
+	{"plain", "2023-10-27 10:00:00 INFO [main] This is a test log message with some numbers 12345 and a url https://example.com"},
+	{"json", `{"time":"2024-05-01T12:01:02Z","level":"error","msg":"request failed","id":"123e4567-e89b-12d3-a456-426614174000"}`},
+	{"logfmt", `time=2024-05-01T12:01:02Z level=warn msg="disk almost full" path=/var/log/syslog ratio=0.93`},
+	{"nomatch", "the quick brown fox jumps over the lazy dog and keeps on running through the field"},
+}
+
 func BenchmarkRender(b *testing.B) {
-	cfg := config.GetDefaultConfig()
-	th := theme.GetDefaultTheme()
-	r, err := New(cfg, th)
-	if err != nil {
-		b.Fatalf("failed to create renderer: %v", err)
+	r := newTestRenderer(b, config.GetDefaultConfig())
+	// without prefilters and fixed-width matching, for comparison
+	naive := *r
+	naive.builtinLower = regexpOnly(r.builtinLower)
+	naive.builtin = regexpOnly(r.builtin)
+
+	for _, bl := range benchmarkLines {
+		b.Run(bl.name, func(b *testing.B) {
+			b.SetBytes(int64(len(bl.line)))
+			b.ReportAllocs()
+			for b.Loop() {
+				_, _ = r.Render(bl.line)
+			}
+		})
+		b.Run(bl.name+"/regexp", func(b *testing.B) {
+			b.SetBytes(int64(len(bl.line)))
+			for b.Loop() {
+				_, _ = naive.Render(bl.line)
+			}
+		})
 	}
+}
 
-	line := "2023-10-27 10:00:00 INFO [main] This is a test log message with some numbers 12345 and a url https://example.com"
+// BenchmarkRenderTo writes into a reused buffer like LineBuffer does, compare
+// its allocations to BenchmarkRender.
+func BenchmarkRenderTo(b *testing.B) {
+	r := newTestRenderer(b, config.GetDefaultConfig())
 
-	for b.Loop() {
-		_, _ = r.Render(line)
+	w := bufio.NewWriter(io.Discard)
+	for _, bl := range benchmarkLines {
+		line := []byte(bl.line)
+		b.Run(bl.name, func(b *testing.B) {
+			b.SetBytes(int64(len(line)))
+			b.ReportAllocs()
+			for b.Loop() {
+				_ = r.RenderTo(w, line)
+			}
+		})
 	}
 }
 
-func TestRender(t *testing.T) {
-	cfg := config.GetDefaultConfig()
-	th := theme.GetDefaultTheme()
-	r, err := New(cfg, th)
+// newTestRenderer returns a renderer for cfg with the default theme.
+func newTestRenderer(t testing.TB, cfg config.Config) *Renderer {
+	t.Helper()
+	r, err := New(cfg, theme.GetDefaultTheme())
 	if err != nil {
 		t.Fatalf("failed to create renderer: %v", err)
 	}
+	return r
+}
+
+// wrap returns text between the escape sequences of group in the theme of r.
+func wrap(r *Renderer, group, text string) string {
+	hl := r.Theme.HighlightMap[group]
+	return hl.BuildAnsi() + text + hl.BuildAnsiReset()
+}
+
+func TestRender(t *testing.T) {
+	r := newTestRenderer(t, config.GetDefaultConfig())
 
 	line := "INFO"
 	out, err := r.Render(line)
@@ -50,14 +102,24 @@ func TestRender(t *testing.T) {
 	}
 }
 
+func TestRender_NoColor(t *testing.T) {
+	r := newTestRenderer(t, config.GetDefaultConfig())
+	r.NoColor = true
+
+	line := "2023-10-27 10:00:00 INFO 12345"
+	out, err := r.Render(line)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if out != line {
+		t.Errorf("expected raw line %q, got %q", line, out)
+	}
+}
+
 func TestRender_UserMatchBackground(t *testing.T) {
 	cfg := config.GetDefaultConfig()
-	th := theme.GetDefaultTheme()
 	cfg.UserSyntax = []proto.Syntax{{Group: "UserPattern", Pattern: proto.MustCompile(`USER\d+`)}}
-	r, err := New(cfg, th)
-	if err != nil {
-		t.Fatalf("failed to create renderer: %v", err)
-	}
+	r := newTestRenderer(t, cfg)
 
 	line := "prefix USER123 suffix"
 	out, err := r.Render(line)
@@ -72,7 +134,7 @@ func TestRender_UserMatchBackground(t *testing.T) {
 		t.Error("expected highlighting, got raw string")
 	}
 
-	userBgHighlight := th.HighlightMap["UserMatchLineBackground"]
+	userBgHighlight := r.Theme.HighlightMap["UserMatchLineBackground"]
 	userBgAnsi := userBgHighlight.BuildAnsi()
 	userBgReset := userBgHighlight.BuildAnsiReset()
 
@@ -91,7 +153,371 @@ func TestRender_UserMatchBackground(t *testing.T) {
 	}
 }
 
-func TestBuildHighlightedString(t *testing.T) {
+func TestRender_ThemeOverridesBuiltInLinks(t *testing.T) {
+	red, green, blue := "#FF0000", "#00FF00", "#0000FF"
+	th := theme.GetDefaultTheme().Extend("custom",
+		style.Highlight{Group: "LogLvError", Fg: &red},
+		style.Highlight{Group: "ErrorMsg", Fg: &green},
+		style.Highlight{Group: "LogLvWarning", Fg: &red},
+	)
+	cfg := config.GetDefaultConfig()
+	// the highlights of the user still override the theme
+	cfg.Highlight = []style.Highlight{{Group: "LogLvWarning", Fg: &blue}}
+	r, err := New(cfg, th)
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+
+	for _, tt := range []struct {
+		line  string
+		color string
+	}{
+		{"ERROR", "38;2;255;0;0"},
+		{"WARN", "38;2;0;0;255"},
+		{"FATAL", "38;2;0;255;0"},
+	} {
+		out, err := r.Render(tt.line)
+		if err != nil {
+			t.Fatalf("render failed: %v", err)
+		}
+		if !strings.Contains(out, tt.color) {
+			t.Errorf("expected %q to be colored %s, got %q", tt.line, tt.color, out)
+		}
+	}
+}
+
+func TestRender_JSON(t *testing.T) {
+	r := newTestRenderer(t, config.GetDefaultConfig())
+
+	line := `{"level":"warn","msg":"took 10ms","count":3,"ok":true}`
+	out, err := r.Render(line)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		wrap(r, "LogJsonKey", `"level"`),
+		wrap(r, "LogLvWarning", `"warn"`),
+		wrap(r, "LogDuration", "10ms"),
+		wrap(r, "LogJsonNumber", "3"),
+		wrap(r, "LogJsonBool", "true"),
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected output to contain %q, got %q", expected, out)
+		}
+	}
+
+	// invalid JSON falls back to the built-in syntax
+	out, err = r.Render(`{"level":"warn"`)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if strings.Contains(out, r.Theme.HighlightMap["LogJsonKey"].BuildAnsi()) {
+		t.Errorf("expected no JSON highlighting for invalid JSON, got %q", out)
+	}
+}
+
+func TestRender_Logfmt(t *testing.T) {
+	cfg := config.GetDefaultConfig()
+	r := newTestRenderer(t, cfg)
+	// logfmt is opt-in, plain text with an = renders as before
+	for _, text := range []string{"a=b", "retry with x=1 later"} {
+		out, err := r.Render(text)
+		if err != nil {
+			t.Fatalf("render failed: %v", err)
+		}
+		for _, group := range []string{"LogfmtKey", "LogfmtValue"} {
+			if strings.Contains(out, r.Theme.HighlightMap[group].BuildAnsi()) {
+				t.Errorf("expected no logfmt highlighting by default, got %q", out)
+			}
+		}
+	}
+
+	cfg.Logfmt.Enabled = true
+	cfg.Logfmt.KeyGroups = map[string]string{"trace_id": "UserPattern"}
+	r = newTestRenderer(t, cfg)
+
+	line := `level=error msg="failed" dur=12ms host=api trace_id=abc123`
+	out, err := r.Render(line)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		wrap(r, "LogfmtKey", "level"),
+		wrap(r, "LogLvError", "error"),
+		wrap(r, "LogDuration", "12ms"),
+		wrap(r, "LogfmtValue", "api"),
+		wrap(r, "UserPattern", "abc123"),
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected output to contain %q, got %q", expected, out)
+		}
+	}
+
+	r.Config.Logfmt.Enabled = false
+	out, err = r.Render(line)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if strings.Contains(out, wrap(r, "LogfmtKey", "level")) {
+		t.Errorf("expected no logfmt highlighting when disabled, got %q", out)
+	}
+}
+
+func TestRender_Captures(t *testing.T) {
+	cfg := config.GetDefaultConfig()
+	cfg.UserSyntax = []proto.Syntax{{
+		Group:   "Comment",
+		Pattern: proto.MustCompile(`(?P<key>\w+)=(?P<val>\w+(:(\d+))?)`),
+		Captures: map[string]string{
+			"key": "LogfmtKey",
+			"val": "String",
+			"4":   "Number",
+		},
+	}}
+	r := newTestRenderer(t, cfg)
+
+	out, err := r.Render("addr=host:8080")
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		wrap(r, "LogfmtKey", "addr"),
+		wrap(r, "Comment", "="),
+		wrap(r, "String", "host:"),
+		wrap(r, "Number", "8080"),
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected output to contain %q, got %q", expected, out)
+		}
+	}
+}
+
+func TestNew_InvalidCaptures(t *testing.T) {
+	tests := []struct {
+		name     string
+		syntax   proto.Syntax
+		expected string
+	}{
+		{
+			name: "unknown name",
+			syntax: proto.Syntax{
+				Pattern:  proto.MustCompile(`(?P<key>\w+)=`),
+				Captures: map[string]string{"value": "String"},
+			},
+			expected: `no capture group "value"`,
+		},
+		{
+			name: "out of range number",
+			syntax: proto.Syntax{
+				Pattern:  proto.MustCompile(`(\w+)=`),
+				Captures: map[string]string{"2": "String"},
+			},
+			expected: `no capture group "2"`,
+		},
+		{
+			name: "unknown highlight group",
+			syntax: proto.Syntax{
+				Pattern:  proto.MustCompile(`(\w+)=`),
+				Captures: map[string]string{"1": "NoSuchGroup"},
+			},
+			expected: "NoSuchGroup not found",
+		},
+		{
+			name: "no pattern",
+			syntax: proto.Syntax{
+				Keywords: []string{"key"},
+				Captures: map[string]string{"1": "String"},
+			},
+			expected: "requires a pattern",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.GetDefaultConfig()
+			cfg.UserSyntax = []proto.Syntax{tt.syntax}
+			_, err := New(cfg, theme.GetDefaultTheme())
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestRender_SyntaxOptions(t *testing.T) {
+	cfg := config.GetDefaultConfig()
+	cfg.UserSyntax = []proto.Syntax{
+		{Group: "UserPattern", Keywords: []string{"Warning"}, IgnoreCase: true},
+		{Group: "UserPattern", Pattern: proto.MustCompile(`err|fail`), IgnoreCase: true, WholeWord: true},
+	}
+	r := newTestRenderer(t, cfg)
+
+	for _, tt := range []struct {
+		text    string
+		matches bool
+	}{
+		{text: "wArNiNg: disk", matches: true},
+		{text: "FAIL now", matches: true},
+		{text: "Err", matches: true},
+		{text: "errors and failures", matches: false},
+		{text: "warnings", matches: false},
+	} {
+		if got := r.MatchesUserSyntax(tt.text); got != tt.matches {
+			t.Errorf("MatchesUserSyntax(%q) = %v, want %v", tt.text, got, tt.matches)
+		}
+	}
+
+	if cfg.UserSyntax[1].Pattern.MatchString("FAIL") {
+		t.Error("applying options must not modify the config patterns")
+	}
+
+	out, err := r.Render("wArN disk")
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if expected := wrap(r, "LogLvWarning", "wArN"); !strings.Contains(out, expected) {
+		t.Errorf("expected levels to match in any case, got %q", out)
+	}
+}
+
+func TestPatternWholeWord(t *testing.T) {
+	tests := []struct {
+		expr     string
+		text     string
+		expected [][]int
+	}{
+		// words are the same as for keywords and literals, not ASCII ones
+		{`foo`, "éfoo foo", [][]int{{6, 9}}},
+		{`é`, "x é y", [][]int{{2, 4}}},
+		{`warn|warning`, "warning warn", [][]int{{0, 7}, {8, 12}}},
+		{`\d+`, "a1 23 4b 5", [][]int{{3, 5}, {9, 10}}},
+	}
+	for _, tt := range tests {
+		p := proto.MustCompile(tt.expr).WithOptions(false, true)
+		if got := p.FindAllStringIndex(tt.text, -1); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("whole word %q on %q: got %v, expected %v", tt.expr, tt.text, got, tt.expected)
+		}
+	}
+
+	p := proto.MustCompile(`(\w+)=(?P<value>\w+)`).WithOptions(false, true)
+	if p.NumSubexp() != 2 || p.CaptureIndex("value") != 2 || p.CaptureIndex("3") != -1 {
+		t.Error("expected whole words to keep the capture groups")
+	}
+	if got := p.FindAllStringSubmatchIndex("k=v", -1); !reflect.DeepEqual(got, [][]int{{0, 3, 0, 1, 2, 3}}) {
+		t.Errorf("unexpected submatches %v", got)
+	}
+}
+
+func TestRender_Literals(t *testing.T) {
+	cfg := config.GetDefaultConfig()
+	cfg.UserSyntax = []proto.Syntax{
+		{Group: "Constant", Literals: []string{"[main]", "10.0.0.1"}},
+		{Group: "Comment", Literals: []string{"id"}, IgnoreCase: true, WholeWord: true},
+		// the same literal in a later group matches where the first doesn't
+		{Group: "String", Literals: []string{"ID"}, IgnoreCase: true},
+	}
+	r := newTestRenderer(t, cfg)
+
+	out, err := r.Render("[main] 10.0.0.1 ID ids")
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	for _, expected := range []string{
+		wrap(r, "Constant", "[main]"),
+		wrap(r, "Constant", "10.0.0.1"),
+		wrap(r, "Comment", "ID"),
+		wrap(r, "String", "id") + "s",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected output to contain %q, got %q", expected, out)
+		}
+	}
+	if strings.Contains(out, wrap(r, "Comment", "id")) {
+		t.Errorf("expected ids not to match as a whole word, got %q", out)
+	}
+
+	if !r.MatchesUserSyntax("10.0.0.1") || r.MatchesUserSyntax("10.0.0.2") {
+		t.Error("expected MatchesUserSyntax to match literals exactly")
+	}
+}
+
+func TestRender_Rainbow(t *testing.T) {
+	cfg := config.GetDefaultConfig()
+	cfg.Rainbow = []string{"LogUUID"}
+	r := newTestRenderer(t, cfg)
+
+	ids := []string{
+		"123e4567-e89b-12d3-a456-426614174000",
+		"223e4567-e89b-12d3-a456-426614174000",
+		"323e4567-e89b-12d3-a456-426614174000",
+		"423e4567-e89b-12d3-a456-426614174000",
+	}
+	colored := make(map[string]bool)
+	for _, id := range ids {
+		hl, ok := r.rainbow.highlight("LogUUID", id)
+		if !ok {
+			t.Fatalf("expected LogUUID to be a rainbow group")
+		}
+		colored[hl.BuildAnsi()] = true
+
+		expected := hl.BuildAnsi() + id + hl.BuildAnsiReset()
+		out, err := r.Render("start " + id + " then " + id)
+		if err != nil {
+			t.Fatalf("render failed: %v", err)
+		}
+		if strings.Count(out, expected) != 2 {
+			t.Errorf("expected both occurrences of %s to be %q, got %q", id, expected, out)
+		}
+	}
+	if len(colored) < 2 {
+		t.Errorf("expected different IDs to get different colors")
+	}
+
+	if _, ok := r.rainbow.highlight("LogMD5", ids[0]); ok {
+		t.Errorf("expected LogMD5 not to be a rainbow group")
+	}
+}
+
+func TestRenderTo(t *testing.T) {
+	cfg := config.GetDefaultConfig()
+	cfg.UserSyntax = []proto.Syntax{{Group: "UserPattern", Pattern: proto.MustCompile(`request \d+`)}}
+	r := newTestRenderer(t, cfg)
+
+	for _, text := range matcherTestLines {
+		expected, err := r.Render(text)
+		if err != nil {
+			t.Fatalf("render failed: %v", err)
+		}
+		var b strings.Builder
+		if err := r.RenderTo(&b, []byte(text)); err != nil {
+			t.Fatalf("render failed: %v", err)
+		}
+		if b.String() != expected {
+			t.Errorf("RenderTo(%q) = %q, Render returned %q", text, b.String(), expected)
+		}
+	}
+
+	if raceEnabled {
+		return
+	}
+	// lines without matches of general regexes are rendered without
+	// allocating
+	w := bufio.NewWriter(io.Discard)
+	for _, bl := range benchmarkLines {
+		if bl.name != "json" && bl.name != "nomatch" {
+			continue
+		}
+		line := []byte(bl.line)
+		allocs := testing.AllocsPerRun(100, func() { _ = r.RenderTo(w, line) })
+		if allocs != 0 {
+			t.Errorf("expected no allocations rendering the %s line, got %v", bl.name, allocs)
+		}
+	}
+}
+
+func TestWriteHighlighted(t *testing.T) {
 	tests := []struct {
 		name     string
 		text     string
@@ -144,8 +570,11 @@ func TestBuildHighlightedString(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := buildHighlightedString(tt.text, tt.matches)
-			if got != tt.expected {
+			var b strings.Builder
+			if err := writeHighlighted(&b, tt.text, tt.matches, "", ""); err != nil {
+				t.Fatalf("writeHighlighted failed: %v", err)
+			}
+			if got := b.String(); got != tt.expected {
 				t.Errorf("expected %q, got %q", tt.expected, got)
 			}
 		})
@@ -0,0 +1,69 @@
+package renderer
+
+import (
+	"sync"
+
+	"github.com/madmaxieee/loglit/internal/ahocorasick"
+)
+
+// scratch holds the buffers used while rendering a line. It is reused across
+// lines through scratchPool, so rendering does not allocate once the buffers
+// have grown to fit.
+type scratch struct {
+	layers    []*MatchLayer
+	lines     []*line
+	jsonStack []bool
+	literals  ahocorasick.Buffer
+	// number of layers and lines handed out
+	usedLayers int
+	usedLines  int
+}
+
+var scratchPool = sync.Pool{
+	New: func() any { return new(scratch) },
+}
+
+func getScratch() *scratch {
+	return scratchPool.Get().(*scratch)
+}
+
+// layer returns an empty MatchLayer backed by a reused buffer. It stays valid
+// until the scratch is released.
+func (s *scratch) layer() *MatchLayer {
+	if s.usedLayers == len(s.layers) {
+		s.layers = append(s.layers, new(MatchLayer))
+	}
+	layer := s.layers[s.usedLayers]
+	*layer = (*layer)[:0]
+	s.usedLayers++
+	return layer
+}
+
+// line returns text as a line backed by reused buffers. It stays valid until
+// the scratch is released.
+func (s *scratch) line(text string) *line {
+	if s.usedLines == len(s.lines) {
+		s.lines = append(s.lines, new(line))
+	}
+	l := s.lines[s.usedLines]
+	l.reset(text)
+	s.usedLines++
+	return l
+}
+
+// stack is Stack writing into a reused buffer.
+func (s *scratch) stack(top MatchLayer, bottom MatchLayer) MatchLayer {
+	out := s.layer()
+	*out = stackInto(*out, top, bottom)
+	return *out
+}
+
+func (s *scratch) release() {
+	// don't keep the rendered text alive
+	for _, l := range s.lines[:s.usedLines] {
+		l.text = ""
+	}
+	s.usedLayers = 0
+	s.usedLines = 0
+	scratchPool.Put(s)
+}
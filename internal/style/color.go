@@ -15,9 +15,7 @@ func Fg(r int, g int, b int) string {
 }
 
 func FgHex(hex string) string {
-	var r, g, b int
-	fmt.Sscanf(hex, "#%02x%02x%02x", &r, &g, &b)
-	return Fg(r, g, b)
+	return Fg(ParseHex(hex))
 }
 
 func Bg(r int, g int, b int) string {
@@ -25,7 +23,60 @@ func Bg(r int, g int, b int) string {
 }
 
 func BgHex(hex string) string {
-	var r, g, b int
+	return Bg(ParseHex(hex))
+}
+
+func Fg256(index int) string {
+	return fmt.Sprintf("%s38;5;%dm", ESCAPE, index)
+}
+
+func Bg256(index int) string {
+	return fmt.Sprintf("%s48;5;%dm", ESCAPE, index)
+}
+
+func Fg16(index int) string {
+	if index < 8 {
+		return fmt.Sprintf("%s%dm", ESCAPE, 30+index)
+	}
+	return fmt.Sprintf("%s%dm", ESCAPE, 90+index-8)
+}
+
+func Bg16(index int) string {
+	if index < 8 {
+		return fmt.Sprintf("%s%dm", ESCAPE, 40+index)
+	}
+	return fmt.Sprintf("%s%dm", ESCAPE, 100+index-8)
+}
+
+// FgHexDepth returns the foreground sequence for a hex color, mapped to the
+// nearest palette entry for the given depth.
+func FgHexDepth(hex string, depth ColorDepth) string {
+	switch depth {
+	case ColorDepthTrueColor:
+		return FgHex(hex)
+	case ColorDepth256:
+		return Fg256(Nearest256(ParseHex(hex)))
+	case ColorDepth16:
+		return Fg16(Nearest16(ParseHex(hex)))
+	}
+	return ""
+}
+
+// BgHexDepth returns the background sequence for a hex color, mapped to the
+// nearest palette entry for the given depth.
+func BgHexDepth(hex string, depth ColorDepth) string {
+	switch depth {
+	case ColorDepthTrueColor:
+		return BgHex(hex)
+	case ColorDepth256:
+		return Bg256(Nearest256(ParseHex(hex)))
+	case ColorDepth16:
+		return Bg16(Nearest16(ParseHex(hex)))
+	}
+	return ""
+}
+
+func ParseHex(hex string) (r int, g int, b int) {
 	fmt.Sscanf(hex, "#%02x%02x%02x", &r, &g, &b)
-	return Bg(r, g, b)
+	return r, g, b
 }
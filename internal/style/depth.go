@@ -0,0 +1,76 @@
+package style
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ColorDepth is the number of colors a terminal can display. The zero value
+// is true color so highlights render unchanged unless a depth is set.
+type ColorDepth int
+
+const (
+	ColorDepthTrueColor ColorDepth = iota
+	ColorDepth256
+	ColorDepth16
+	ColorDepthNone
+)
+
+func (d ColorDepth) String() string {
+	switch d {
+	case ColorDepthTrueColor:
+		return "truecolor"
+	case ColorDepth256:
+		return "256"
+	case ColorDepth16:
+		return "16"
+	case ColorDepthNone:
+		return "none"
+	}
+	return fmt.Sprintf("ColorDepth(%d)", int(d))
+}
+
+// ParseColorDepth parses a --color-depth value. "auto" detects the depth from
+// the environment.
+func ParseColorDepth(s string) (ColorDepth, error) {
+	switch strings.ToLower(s) {
+	case "", "auto":
+		return DetectColorDepth(), nil
+	case "truecolor", "24bit":
+		return ColorDepthTrueColor, nil
+	case "256":
+		return ColorDepth256, nil
+	case "16":
+		return ColorDepth16, nil
+	case "none":
+		return ColorDepthNone, nil
+	}
+	return ColorDepthTrueColor, fmt.Errorf("invalid color depth %q, expected one of auto, truecolor, 256, 16, none", s)
+}
+
+// DetectColorDepth guesses the color depth of the terminal from the COLORTERM
+// and TERM environment variables.
+func DetectColorDepth() ColorDepth {
+	return detectColorDepth(os.Getenv("COLORTERM"), os.Getenv("TERM"))
+}
+
+func detectColorDepth(colorterm string, term string) ColorDepth {
+	colorterm = strings.ToLower(colorterm)
+	term = strings.ToLower(term)
+
+	if colorterm == "truecolor" || colorterm == "24bit" {
+		return ColorDepthTrueColor
+	}
+	switch {
+	case term == "dumb":
+		return ColorDepthNone
+	case strings.Contains(term, "truecolor"),
+		strings.Contains(term, "24bit"),
+		strings.Contains(term, "direct"):
+		return ColorDepthTrueColor
+	case strings.Contains(term, "256color"):
+		return ColorDepth256
+	}
+	return ColorDepth16
+}
@@ -0,0 +1,97 @@
+package style
+
+import (
+	"testing"
+
+	"github.com/madmaxieee/loglit/internal/utils"
+)
+
+func TestDetectColorDepth(t *testing.T) {
+	tests := []struct {
+		colorterm string
+		term      string
+		expected  ColorDepth
+	}{
+		{colorterm: "truecolor", term: "xterm-256color", expected: ColorDepthTrueColor},
+		{colorterm: "24bit", term: "", expected: ColorDepthTrueColor},
+		{colorterm: "", term: "xterm-direct", expected: ColorDepthTrueColor},
+		{colorterm: "", term: "tmux-256color", expected: ColorDepth256},
+		{colorterm: "", term: "xterm", expected: ColorDepth16},
+		{colorterm: "", term: "linux", expected: ColorDepth16},
+		{colorterm: "", term: "", expected: ColorDepth16},
+		{colorterm: "", term: "dumb", expected: ColorDepthNone},
+	}
+
+	for _, tt := range tests {
+		got := detectColorDepth(tt.colorterm, tt.term)
+		if got != tt.expected {
+			t.Errorf("COLORTERM=%q TERM=%q: expected %v, got %v", tt.colorterm, tt.term, tt.expected, got)
+		}
+	}
+}
+
+func TestNearest256(t *testing.T) {
+	tests := []struct {
+		hex      string
+		expected int
+	}{
+		{hex: "#000000", expected: 16},
+		{hex: "#FFFFFF", expected: 231},
+		{hex: "#FF0000", expected: 196},
+		{hex: "#5F87AF", expected: 67},
+		{hex: "#808080", expected: 244},
+		{hex: "#121212", expected: 233},
+	}
+
+	for _, tt := range tests {
+		got := Nearest256(ParseHex(tt.hex))
+		if got != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.hex, tt.expected, got)
+		}
+	}
+}
+
+func TestNearest16(t *testing.T) {
+	tests := []struct {
+		hex      string
+		expected int
+	}{
+		{hex: "#000000", expected: 0},
+		{hex: "#FFFFFF", expected: 15},
+		{hex: "#C53B53", expected: 1},
+		{hex: "#C3E88D", expected: 7},
+		{hex: "#00FF00", expected: 10},
+	}
+
+	for _, tt := range tests {
+		got := Nearest16(ParseHex(tt.hex))
+		if got != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.hex, tt.expected, got)
+		}
+	}
+}
+
+func TestBuildAnsiColorDepth(t *testing.T) {
+	hl := Highlight{Group: "Test", Fg: utils.Ptr("#FF0000"), Bg: utils.Ptr("#000000"), Bold: true}
+
+	tests := []struct {
+		depth     ColorDepth
+		ansi      string
+		ansiReset string
+	}{
+		{ColorDepthTrueColor, "\x1b[38;2;255;0;0m\x1b[48;2;0;0;0m\x1b[1m", "\x1b[39m\x1b[49m\x1b[22m"},
+		{ColorDepth256, "\x1b[38;5;196m\x1b[48;5;16m\x1b[1m", "\x1b[39m\x1b[49m\x1b[22m"},
+		{ColorDepth16, "\x1b[91m\x1b[40m\x1b[1m", "\x1b[39m\x1b[49m\x1b[22m"},
+		{ColorDepthNone, "\x1b[1m", "\x1b[22m"},
+	}
+
+	for _, tt := range tests {
+		hl.SetColorDepth(tt.depth)
+		if got := hl.BuildAnsi(); got != tt.ansi {
+			t.Errorf("%v: expected ansi %q, got %q", tt.depth, tt.ansi, got)
+		}
+		if got := hl.BuildAnsiReset(); got != tt.ansiReset {
+			t.Errorf("%v: expected ansi reset %q, got %q", tt.depth, tt.ansiReset, got)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package style
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ColorMode controls whether colored output is produced at all.
+type ColorMode int
+
+const (
+	ColorModeAuto ColorMode = iota
+	ColorModeAlways
+	ColorModeNever
+)
+
+func (m ColorMode) String() string {
+	switch m {
+	case ColorModeAuto:
+		return "auto"
+	case ColorModeAlways:
+		return "always"
+	case ColorModeNever:
+		return "never"
+	}
+	return fmt.Sprintf("ColorMode(%d)", int(m))
+}
+
+// ParseColorMode parses a --color value.
+func ParseColorMode(s string) (ColorMode, error) {
+	switch strings.ToLower(s) {
+	case "", "auto":
+		return ColorModeAuto, nil
+	case "always", "force":
+		return ColorModeAlways, nil
+	case "never", "none":
+		return ColorModeNever, nil
+	}
+	return ColorModeAuto, fmt.Errorf("invalid color mode %q, expected one of auto, always, never", s)
+}
+
+// ColorModeFromEnv returns the color mode requested by the FORCE_COLOR,
+// CLICOLOR_FORCE and NO_COLOR conventions, in that order of precedence.
+func ColorModeFromEnv() ColorMode {
+	return colorModeFromEnv(os.LookupEnv)
+}
+
+func colorModeFromEnv(lookupEnv func(string) (string, bool)) ColorMode {
+	if v, ok := lookupEnv("FORCE_COLOR"); ok {
+		switch strings.ToLower(v) {
+		case "0", "false":
+			return ColorModeNever
+		default:
+			// an empty FORCE_COLOR also forces color, see https://force-color.org
+			return ColorModeAlways
+		}
+	}
+	if v, ok := lookupEnv("CLICOLOR_FORCE"); ok && v != "" && v != "0" {
+		return ColorModeAlways
+	}
+	if v, ok := lookupEnv("NO_COLOR"); ok && v != "" {
+		return ColorModeNever
+	}
+	return ColorModeAuto
+}
+
+// Enabled reports whether output to a writer should be colored.
+func (m ColorMode) Enabled(isTerminal bool) bool {
+	switch m {
+	case ColorModeAlways:
+		return true
+	case ColorModeNever:
+		return false
+	}
+	return isTerminal
+}
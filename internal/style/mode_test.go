@@ -0,0 +1,33 @@
+package style
+
+import "testing"
+
+func TestColorModeFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected ColorMode
+	}{
+		{name: "unset", env: map[string]string{}, expected: ColorModeAuto},
+		{name: "NO_COLOR", env: map[string]string{"NO_COLOR": "1"}, expected: ColorModeNever},
+		{name: "empty NO_COLOR", env: map[string]string{"NO_COLOR": ""}, expected: ColorModeAuto},
+		{name: "FORCE_COLOR", env: map[string]string{"FORCE_COLOR": "1"}, expected: ColorModeAlways},
+		{name: "empty FORCE_COLOR", env: map[string]string{"FORCE_COLOR": ""}, expected: ColorModeAlways},
+		{name: "FORCE_COLOR=0", env: map[string]string{"FORCE_COLOR": "0"}, expected: ColorModeNever},
+		{name: "CLICOLOR_FORCE", env: map[string]string{"CLICOLOR_FORCE": "1"}, expected: ColorModeAlways},
+		{name: "CLICOLOR_FORCE=0", env: map[string]string{"CLICOLOR_FORCE": "0"}, expected: ColorModeAuto},
+		{name: "FORCE_COLOR over NO_COLOR", env: map[string]string{"FORCE_COLOR": "1", "NO_COLOR": "1"}, expected: ColorModeAlways},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := colorModeFromEnv(func(key string) (string, bool) {
+				v, ok := tt.env[key]
+				return v, ok
+			})
+			if got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package style
+
+// palette16 holds the xterm default values of the 16 basic ANSI colors.
+var palette16 = [16][3]int{
+	{0, 0, 0},
+	{205, 0, 0},
+	{0, 205, 0},
+	{205, 205, 0},
+	{0, 0, 238},
+	{205, 0, 205},
+	{0, 205, 205},
+	{229, 229, 229},
+	{127, 127, 127},
+	{255, 0, 0},
+	{0, 255, 0},
+	{255, 255, 0},
+	{92, 92, 255},
+	{255, 0, 255},
+	{0, 255, 255},
+	{255, 255, 255},
+}
+
+// cubeLevels are the channel values of the 6x6x6 color cube in the 256 color
+// palette.
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// colorDistance approximates perceived distance between two colors using the
+// "redmean" weighting.
+func colorDistance(r1, g1, b1, r2, g2, b2 int) int {
+	rmean := (r1 + r2) / 2
+	dr, dg, db := r1-r2, g1-g2, b1-b2
+	return ((512+rmean)*dr*dr)>>8 + 4*dg*dg + ((767-rmean)*db*db)>>8
+}
+
+func nearestCubeLevel(v int) int {
+	best := 0
+	for i, level := range cubeLevels {
+		if abs(v-level) < abs(v-cubeLevels[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// Nearest256 returns the index of the closest color in the xterm 256 color
+// palette, considering the color cube and the grayscale ramp. The first 16
+// colors are skipped since terminals commonly redefine them.
+func Nearest256(r, g, b int) int {
+	ri, gi, bi := nearestCubeLevel(r), nearestCubeLevel(g), nearestCubeLevel(b)
+	cubeIndex := 16 + 36*ri + 6*gi + bi
+	cubeDist := colorDistance(r, g, b, cubeLevels[ri], cubeLevels[gi], cubeLevels[bi])
+
+	// grayscale ramp: 232..255 map to 8, 18, ..., 238
+	gray := (r + g + b) / 3
+	grayStep := min(max((gray-8+5)/10, 0), 23)
+	grayValue := 8 + 10*grayStep
+	grayDist := colorDistance(r, g, b, grayValue, grayValue, grayValue)
+
+	if grayDist < cubeDist {
+		return 232 + grayStep
+	}
+	return cubeIndex
+}
+
+// Nearest16 returns the index of the closest basic ANSI color.
+func Nearest16(r, g, b int) int {
+	best, bestDist := 0, -1
+	for i, c := range palette16 {
+		dist := colorDistance(r, g, b, c[0], c[1], c[2])
+		if bestDist < 0 || dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+	return best
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,97 @@
+package style
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// colorNames maps the names accepted by ParseSpec to the xterm values of the
+// 16 basic colors.
+var colorNames = map[string]int{
+	"black":          0,
+	"red":            1,
+	"green":          2,
+	"yellow":         3,
+	"blue":           4,
+	"magenta":        5,
+	"cyan":           6,
+	"white":          7,
+	"gray":           8,
+	"grey":           8,
+	"bright-red":     9,
+	"bright-green":   10,
+	"bright-yellow":  11,
+	"bright-blue":    12,
+	"bright-magenta": 13,
+	"bright-cyan":    14,
+	"bright-white":   15,
+}
+
+var hexColorRe = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// parseColor returns the hex value of a color name or hex string.
+func parseColor(s string) (string, error) {
+	if hexColorRe.MatchString(s) {
+		return strings.ToUpper(s), nil
+	}
+	index, ok := colorNames[strings.ToLower(s)]
+	if !ok {
+		return "", fmt.Errorf("invalid color %q, expected a color name or #RRGGBB", s)
+	}
+	c := palette16[index]
+	return fmt.Sprintf("#%02X%02X%02X", c[0], c[1], c[2]), nil
+}
+
+// ParseSpec parses a compact style such as "red,bold" or "#ffffff,bg=blue".
+// It is a comma separated list of a foreground color, "bg=" followed by a
+// background color and the attributes bold, italic and underline.
+func ParseSpec(s string) (HighlightSpec, error) {
+	var spec HighlightSpec
+	if strings.TrimSpace(s) == "" {
+		return spec, fmt.Errorf("empty style")
+	}
+	for _, token := range strings.Split(s, ",") {
+		token = strings.TrimSpace(token)
+		switch {
+		case token == "bold":
+			spec.Bold = true
+		case token == "italic":
+			spec.Italic = true
+		case token == "underline":
+			spec.Underline = true
+		case strings.HasPrefix(token, "bg="):
+			color, err := parseColor(strings.TrimPrefix(token, "bg="))
+			if err != nil {
+				return spec, fmt.Errorf("invalid style %q: %w", s, err)
+			}
+			spec.Bg = &color
+		default:
+			color, err := parseColor(strings.TrimPrefix(token, "fg="))
+			if err != nil {
+				return spec, fmt.Errorf("invalid style %q: %w", s, err)
+			}
+			spec.Fg = &color
+		}
+	}
+	return spec, nil
+}
+
+// ParseColors checks the colors of s, as read from a config or theme file,
+// and turns color names into hex values like ParseSpec does.
+func (s *HighlightSpec) ParseColors() error {
+	for _, c := range []struct {
+		key   string
+		color **string
+	}{{"fg", &s.Fg}, {"bg", &s.Bg}} {
+		if *c.color == nil || hexColorRe.MatchString(**c.color) {
+			continue
+		}
+		color, err := parseColor(**c.color)
+		if err != nil {
+			return fmt.Errorf("%s: %w", c.key, err)
+		}
+		*c.color = &color
+	}
+	return nil
+}
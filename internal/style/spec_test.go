@@ -0,0 +1,25 @@
+package style
+
+import "testing"
+
+func TestParseSpec(t *testing.T) {
+	spec, err := ParseSpec("red,bold,bg=#00ff00,underline")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if spec.Fg == nil || *spec.Fg != "#CD0000" {
+		t.Errorf("expected fg #CD0000, got %v", spec.Fg)
+	}
+	if spec.Bg == nil || *spec.Bg != "#00FF00" {
+		t.Errorf("expected bg #00FF00, got %v", spec.Bg)
+	}
+	if !spec.Bold || !spec.Underline || spec.Italic {
+		t.Errorf("unexpected attributes %+v", spec)
+	}
+
+	for _, invalid := range []string{"", "reddish", "bg=#fff", "bold,"} {
+		if _, err := ParseSpec(invalid); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+}
@@ -3,10 +3,11 @@ package style
 import (
 	"strings"
 
-	"github.com/madmaxieee/loglit/internal/utils"
 	"github.com/pelletier/go-toml/v2"
 )
 
+// Highlight describes how a group is rendered. Colors are hex strings that
+// are converted to escape sequences for the highlight's color depth.
 type Highlight struct {
 	Group     string
 	Link      *string
@@ -15,39 +16,92 @@ type Highlight struct {
 	Italic    bool
 	Bold      bool
 	Underline bool
+	depth     ColorDepth
 	ansi      *string
 	ansiReset *string
 }
 
+// HighlightSpec is a Highlight as written in config and theme files, with
+// colors given as hex strings.
+type HighlightSpec struct {
+	Group     string  `toml:"group"`
+	Link      *string `toml:"link"`
+	Fg        *string `toml:"fg"`
+	Bg        *string `toml:"bg"`
+	Italic    bool    `toml:"italic"`
+	Bold      bool    `toml:"bold"`
+	Underline bool    `toml:"underline"`
+}
+
+func (s HighlightSpec) Highlight() Highlight {
+	h := Highlight{
+		Group:     s.Group,
+		Link:      s.Link,
+		Italic:    s.Italic,
+		Bold:      s.Bold,
+		Underline: s.Underline,
+	}
+	if s.Link == nil {
+		h.Fg = s.Fg
+		h.Bg = s.Bg
+	}
+	return h
+}
+
 func (h *Highlight) UnmarshalText(text []byte) error {
 	err := toml.Unmarshal(text, h)
 	if err != nil {
 		return err
 	}
-	if h.Link == nil {
-		if h.Fg != nil {
-			h.Fg = utils.Ptr(FgHex(*h.Fg))
-		}
-		if h.Bg != nil {
-			h.Bg = utils.Ptr(BgHex(*h.Bg))
-		}
-	} else {
+	if h.Link != nil {
 		h.Fg = nil
 		h.Bg = nil
 	}
 	return nil
 }
 
+func (h Highlight) ColorDepth() ColorDepth {
+	return h.depth
+}
+
+// SetColorDepth sets the color depth the highlight is rendered for.
+func (h *Highlight) SetColorDepth(depth ColorDepth) {
+	if h.depth != depth {
+		h.ansi = nil
+		h.ansiReset = nil
+	}
+	h.depth = depth
+}
+
+func (h Highlight) hasFg() bool {
+	return h.Fg != nil && h.depth != ColorDepthNone
+}
+
+func (h Highlight) hasBg() bool {
+	return h.Bg != nil && h.depth != ColorDepthNone
+}
+
+// CacheAnsi builds the escape sequences of the highlight once, so BuildAnsi
+// and BuildAnsiReset return them without rebuilding. Changing the color depth
+// drops the cache, changing any other field requires calling CacheAnsi again.
+func (h *Highlight) CacheAnsi() {
+	h.ansi = nil
+	h.ansiReset = nil
+	ansi, ansiReset := h.BuildAnsi(), h.BuildAnsiReset()
+	h.ansi = &ansi
+	h.ansiReset = &ansiReset
+}
+
 func (h Highlight) BuildAnsi() string {
 	if h.ansi != nil {
 		return *h.ansi
 	}
 	var b strings.Builder
-	if h.Fg != nil {
-		b.WriteString(*h.Fg)
+	if h.hasFg() {
+		b.WriteString(FgHexDepth(*h.Fg, h.depth))
 	}
-	if h.Bg != nil {
-		b.WriteString(*h.Bg)
+	if h.hasBg() {
+		b.WriteString(BgHexDepth(*h.Bg, h.depth))
 	}
 	if h.Bold {
 		b.WriteString(BoldAnsi)
@@ -58,8 +112,7 @@ func (h Highlight) BuildAnsi() string {
 	if h.Underline {
 		b.WriteString(UnderlineAnsi)
 	}
-	h.ansi = utils.Ptr(b.String())
-	return *h.ansi
+	return b.String()
 }
 
 func (h Highlight) BuildAnsiReset() string {
@@ -67,10 +120,10 @@ func (h Highlight) BuildAnsiReset() string {
 		return *h.ansiReset
 	}
 	var b strings.Builder
-	if h.Fg != nil {
+	if h.hasFg() {
 		b.WriteString(ResetFgAnsi)
 	}
-	if h.Bg != nil {
+	if h.hasBg() {
 		b.WriteString(ResetBgAnsi)
 	}
 	if h.Bold {
@@ -82,6 +135,5 @@ func (h Highlight) BuildAnsiReset() string {
 	if h.Underline {
 		b.WriteString(ResetUnderlineAnsi)
 	}
-	h.ansiReset = utils.Ptr(b.String())
-	return *h.ansiReset
+	return b.String()
 }
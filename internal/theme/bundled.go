@@ -0,0 +1,100 @@
+package theme
+
+// LightTheme is derived from the tokyonight day palette for terminals with a
+// light background.
+var LightTheme = DefaultTheme.Extend("light",
+	highlight{Group: "Constant", Fg: fg("#B15C00")},
+	highlight{Group: "Special", Fg: fg("#188092")},
+	highlight{Group: "Comment", Fg: fg("#848CB5"), Italic: true},
+	highlight{Group: "String", Fg: fg("#587539")},
+	highlight{Group: "Type", Fg: fg("#2E7DE9")},
+	highlight{Group: "Operator", Fg: fg("#006A83")},
+	highlight{Group: "Statement", Fg: fg("#7847BD")},
+	highlight{Group: "Function", Fg: fg("#2E7DE9")},
+	highlight{Group: "ErrorMsg", Fg: fg("#C64343")},
+	highlight{Group: "WarningMsg", Fg: fg("#8C6C3E")},
+	highlight{Group: "Debug", Fg: fg("#B15C00")},
+	highlight{Group: "LogGreen", Fg: fg("#587539")},
+	highlight{Group: "LogBlue", Fg: fg("#2E7DE9")},
+	highlight{Group: "UserPattern", Fg: fg("#E1E2E7"), Bg: bg("#7847BD"), Bold: true},
+	highlight{Group: "UserPattern1", Fg: fg("#E1E2E7"), Bg: bg("#7847BD"), Bold: true},
+	highlight{Group: "UserPattern2", Fg: fg("#E1E2E7"), Bg: bg("#2E7DE9"), Bold: true},
+	highlight{Group: "UserPattern3", Fg: fg("#E1E2E7"), Bg: bg("#587539"), Bold: true},
+	highlight{Group: "UserPattern4", Fg: fg("#E1E2E7"), Bg: bg("#B15C00"), Bold: true},
+	highlight{Group: "UserPattern5", Fg: fg("#E1E2E7"), Bg: bg("#8C6C3E"), Bold: true},
+	highlight{Group: "UserPattern6", Fg: fg("#E1E2E7"), Bg: bg("#007197"), Bold: true},
+	highlight{Group: "UserMatchLineBackground", Bg: bg("#DCD3F0")},
+	highlight{Group: "LogSource1", Fg: fg("#2E7DE9"), Bold: true},
+	highlight{Group: "LogSource2", Fg: fg("#587539"), Bold: true},
+	highlight{Group: "LogSource3", Fg: fg("#B15C00"), Bold: true},
+	highlight{Group: "LogSource4", Fg: fg("#7847BD"), Bold: true},
+	highlight{Group: "LogSource5", Fg: fg("#007197"), Bold: true},
+	highlight{Group: "LogSource6", Fg: fg("#8C6C3E"), Bold: true},
+	highlight{Group: "LogSource7", Fg: fg("#D20065"), Bold: true},
+	highlight{Group: "LogSource8", Fg: fg("#118C74"), Bold: true},
+	highlight{Group: "LogRainbow1", Fg: fg("#2E7DE9")},
+	highlight{Group: "LogRainbow2", Fg: fg("#587539")},
+	highlight{Group: "LogRainbow3", Fg: fg("#B15C00")},
+	highlight{Group: "LogRainbow4", Fg: fg("#7847BD")},
+	highlight{Group: "LogRainbow5", Fg: fg("#007197")},
+	highlight{Group: "LogRainbow6", Fg: fg("#8C6C3E")},
+	highlight{Group: "LogRainbow7", Fg: fg("#D20065")},
+	highlight{Group: "LogRainbow8", Fg: fg("#118C74")},
+	highlight{Group: "LogRainbow9", Fg: fg("#C64343")},
+	highlight{Group: "LogRainbow10", Fg: fg("#9854F1")},
+	highlight{Group: "LogRainbow11", Fg: fg("#006A83")},
+	highlight{Group: "LogRainbow12", Fg: fg("#A5620B")},
+)
+
+// HighContrastTheme uses saturated colors and bold levels for readability on
+// both dark and low quality displays.
+var HighContrastTheme = DefaultTheme.Extend("high-contrast",
+	highlight{Group: "Constant", Fg: fg("#FFAF00")},
+	highlight{Group: "Special", Fg: fg("#00FFFF")},
+	highlight{Group: "Comment", Fg: fg("#B2B2B2"), Italic: true},
+	highlight{Group: "String", Fg: fg("#00FF00")},
+	highlight{Group: "Type", Fg: fg("#00D7FF")},
+	highlight{Group: "Operator", Fg: fg("#5FD7FF")},
+	highlight{Group: "Statement", Fg: fg("#FF87FF")},
+	highlight{Group: "Function", Fg: fg("#87AFFF")},
+	highlight{Group: "Underlined", Underline: true, Bold: true},
+	highlight{Group: "ErrorMsg", Fg: fg("#FFFFFF"), Bg: bg("#D70000"), Bold: true},
+	highlight{Group: "WarningMsg", Fg: fg("#FFFF00"), Bold: true},
+	highlight{Group: "Debug", Fg: fg("#FFAF00")},
+	highlight{Group: "LogGreen", Fg: fg("#00FF00"), Bold: true},
+	highlight{Group: "LogBlue", Fg: fg("#00AFFF"), Bold: true},
+	highlight{Group: "UserPattern", Fg: fg("#000000"), Bg: bg("#FFFF00"), Bold: true},
+	highlight{Group: "UserPattern1", Fg: fg("#000000"), Bg: bg("#FFFF00"), Bold: true},
+	highlight{Group: "UserPattern2", Fg: fg("#000000"), Bg: bg("#00FFFF"), Bold: true},
+	highlight{Group: "UserPattern3", Fg: fg("#000000"), Bg: bg("#FF87FF"), Bold: true},
+	highlight{Group: "UserPattern4", Fg: fg("#000000"), Bg: bg("#00FF00"), Bold: true},
+	highlight{Group: "UserPattern5", Fg: fg("#000000"), Bg: bg("#FFAF00"), Bold: true},
+	highlight{Group: "UserPattern6", Fg: fg("#000000"), Bg: bg("#5FAFFF"), Bold: true},
+	highlight{Group: "UserMatchLineBackground", Bg: bg("#303030")},
+	highlight{Group: "LogSource1", Fg: fg("#5FAFFF"), Bold: true},
+	highlight{Group: "LogSource2", Fg: fg("#00FF00"), Bold: true},
+	highlight{Group: "LogSource3", Fg: fg("#FFAF00"), Bold: true},
+	highlight{Group: "LogSource4", Fg: fg("#FF87FF"), Bold: true},
+	highlight{Group: "LogSource5", Fg: fg("#00FFFF"), Bold: true},
+	highlight{Group: "LogSource6", Fg: fg("#FFFF00"), Bold: true},
+	highlight{Group: "LogSource7", Fg: fg("#FF5FAF"), Bold: true},
+	highlight{Group: "LogSource8", Fg: fg("#5FFFD7"), Bold: true},
+	highlight{Group: "LogRainbow1", Fg: fg("#5FAFFF")},
+	highlight{Group: "LogRainbow2", Fg: fg("#00FF00")},
+	highlight{Group: "LogRainbow3", Fg: fg("#FFAF00")},
+	highlight{Group: "LogRainbow4", Fg: fg("#FF87FF")},
+	highlight{Group: "LogRainbow5", Fg: fg("#00FFFF")},
+	highlight{Group: "LogRainbow6", Fg: fg("#FFFF00")},
+	highlight{Group: "LogRainbow7", Fg: fg("#FF5FAF")},
+	highlight{Group: "LogRainbow8", Fg: fg("#5FFFD7")},
+	highlight{Group: "LogRainbow9", Fg: fg("#FF5F5F")},
+	highlight{Group: "LogRainbow10", Fg: fg("#AFAFFF")},
+	highlight{Group: "LogRainbow11", Fg: fg("#87FF5F")},
+	highlight{Group: "LogRainbow12", Fg: fg("#FFD787")},
+)
+
+var bundledThemes = map[string]*Theme{
+	DefaultTheme.Name:      &DefaultTheme,
+	LightTheme.Name:        &LightTheme,
+	HighContrastTheme.Name: &HighContrastTheme,
+}
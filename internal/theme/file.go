@@ -0,0 +1,118 @@
+package theme
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/madmaxieee/loglit/internal/style"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// File is the on-disk representation of a theme. The theme is named after
+// the file and only needs to define the groups it changes from the theme it
+// extends.
+//
+//	extends = "light"
+//
+//	[highlight.ErrorMsg]
+//	fg = "#FF0000"
+//	bold = true
+//
+//	[highlight.Label]
+//	link = "Type"
+type File struct {
+	Extends   string                         `toml:"extends"`
+	Highlight map[string]style.HighlightSpec `toml:"highlight"`
+}
+
+// Names returns the names of the bundled themes.
+func Names() []string {
+	names := make([]string, 0, len(bundledThemes))
+	for name := range bundledThemes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Get returns a copy of the bundled theme with the given name.
+func Get(name string) (Theme, error) {
+	th, ok := bundledThemes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("theme %q not found, available themes: %s", name, strings.Join(Names(), ", "))
+	}
+	return th.Clone(), nil
+}
+
+// Load returns the theme with the given name, preferring <dir>/<name>.toml
+// over the bundled themes. An empty dir only looks up bundled themes.
+func Load(name string, dir string) (Theme, error) {
+	return load(name, dir, make(map[string]bool))
+}
+
+func load(name string, dir string, visited map[string]bool) (Theme, error) {
+	if dir == "" {
+		return Get(name)
+	}
+	if visited[name] {
+		return Theme{}, fmt.Errorf("theme extends cycle detected for %q", name)
+	}
+	visited[name] = true
+
+	path := filepath.Join(dir, name+".toml")
+	file, err := ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Get(name)
+	}
+	if err != nil {
+		return Theme{}, err
+	}
+
+	base := file.Extends
+	if base == "" {
+		base = DefaultTheme.Name
+	}
+	var th Theme
+	if base == name {
+		// a user theme may shadow a bundled theme while extending it
+		th, err = Get(base)
+	} else {
+		th, err = load(base, dir, visited)
+	}
+	if err != nil {
+		return Theme{}, err
+	}
+
+	th.Name = name
+	for group, spec := range file.Highlight {
+		spec.Group = group
+		th.Insert(spec.Highlight())
+	}
+	return th, nil
+}
+
+// ReadFile parses the theme file at path.
+func ReadFile(path string) (File, error) {
+	var file File
+	f, err := os.Open(path)
+	if err != nil {
+		return file, err
+	}
+	defer f.Close()
+
+	err = toml.NewDecoder(f).DisallowUnknownFields().Decode(&file)
+	if err != nil {
+		return file, fmt.Errorf("failed to parse theme file %s: %w", path, err)
+	}
+	for group, spec := range file.Highlight {
+		if err := spec.ParseColors(); err != nil {
+			return file, fmt.Errorf("theme file %s: highlight %q: %w", path, group, err)
+		}
+		file.Highlight[group] = spec
+	}
+	return file, nil
+}
@@ -0,0 +1,75 @@
+package theme
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBundledThemesResolve(t *testing.T) {
+	for _, name := range Names() {
+		th, err := Get(name)
+		if err != nil {
+			t.Fatalf("failed to get theme %q: %v", name, err)
+		}
+		if th.Name != name {
+			t.Errorf("expected theme name %q, got %q", name, th.Name)
+		}
+		if err := th.ResolveAllLinks(); err != nil {
+			t.Errorf("failed to resolve links for theme %q: %v", name, err)
+		}
+	}
+}
+
+func TestLoadFromDir(t *testing.T) {
+	dir := t.TempDir()
+	content := `
+extends = "light"
+
+[highlight.ErrorMsg]
+fg = "#FF0000"
+bold = true
+`
+	if err := os.WriteFile(filepath.Join(dir, "mine.toml"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write theme file: %v", err)
+	}
+
+	th, err := Load("mine", dir)
+	if err != nil {
+		t.Fatalf("failed to load theme: %v", err)
+	}
+	if th.Name != "mine" {
+		t.Errorf("expected theme name %q, got %q", "mine", th.Name)
+	}
+
+	errorMsg := th.HighlightMap["ErrorMsg"]
+	if errorMsg.Fg == nil || *errorMsg.Fg != "#FF0000" || !errorMsg.Bold {
+		t.Errorf("unexpected ErrorMsg highlight: %+v", errorMsg)
+	}
+	if *th.HighlightMap["String"].Fg != *LightTheme.HighlightMap["String"].Fg {
+		t.Error("expected String to be inherited from the light theme")
+	}
+	if LightTheme.HighlightMap["ErrorMsg"].Bold {
+		t.Error("loading a theme must not modify the theme it extends")
+	}
+
+	if _, err := Load("light", dir); err != nil {
+		t.Errorf("expected bundled theme fallback, got %v", err)
+	}
+	if _, err := Load("missing", dir); err == nil {
+		t.Error("expected an error for an unknown theme")
+	}
+}
+
+func TestLoadInvalidColor(t *testing.T) {
+	dir := t.TempDir()
+	content := "[highlight.ErrorMsg]\nfg = \"#FF00\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "bad.toml"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write theme file: %v", err)
+	}
+	_, err := Load("bad", dir)
+	if err == nil || !strings.Contains(err.Error(), "ErrorMsg") {
+		t.Errorf("expected an error naming the group, got %v", err)
+	}
+}
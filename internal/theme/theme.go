@@ -14,14 +14,15 @@ type Theme struct {
 	Name         string
 	HighlightMap map[string]*highlight
 	linked       bool
+	colorDepth   style.ColorDepth
 }
 
 func fg(raw string) *string {
-	return utils.Ptr(style.FgHex(raw))
+	return utils.Ptr(raw)
 }
 
 func bg(raw string) *string {
-	return utils.Ptr(style.BgHex(raw))
+	return utils.Ptr(raw)
 }
 
 var DefaultTheme = Theme{
@@ -117,15 +118,75 @@ var DefaultTheme = Theme{
 			Bg:    bg("#C099FF"),
 			Bold:  true,
 		},
+		// distinct colors for each pattern given on the command line
+		"UserPattern1": {Group: "UserPattern1", Fg: fg("#222436"), Bg: bg("#C099FF"), Bold: true},
+		"UserPattern2": {Group: "UserPattern2", Fg: fg("#222436"), Bg: bg("#82AAFF"), Bold: true},
+		"UserPattern3": {Group: "UserPattern3", Fg: fg("#222436"), Bg: bg("#C3E88D"), Bold: true},
+		"UserPattern4": {Group: "UserPattern4", Fg: fg("#222436"), Bg: bg("#FF966C"), Bold: true},
+		"UserPattern5": {Group: "UserPattern5", Fg: fg("#222436"), Bg: bg("#FFC777"), Bold: true},
+		"UserPattern6": {Group: "UserPattern6", Fg: fg("#222436"), Bg: bg("#86E1FC"), Bold: true},
 		"UserMatchLineBackground": {
 			Group: "UserMatchLineBackground",
 			Bg:    bg("#403355"),
 		},
+		// source prefixes for multiple inputs
+		"LogSource1": {Group: "LogSource1", Fg: fg("#82AAFF"), Bold: true},
+		"LogSource2": {Group: "LogSource2", Fg: fg("#C3E88D"), Bold: true},
+		"LogSource3": {Group: "LogSource3", Fg: fg("#FF966C"), Bold: true},
+		"LogSource4": {Group: "LogSource4", Fg: fg("#C099FF"), Bold: true},
+		"LogSource5": {Group: "LogSource5", Fg: fg("#86E1FC"), Bold: true},
+		"LogSource6": {Group: "LogSource6", Fg: fg("#FFC777"), Bold: true},
+		"LogSource7": {Group: "LogSource7", Fg: fg("#FCA7EA"), Bold: true},
+		"LogSource8": {Group: "LogSource8", Fg: fg("#4FD6BE"), Bold: true},
+		// marker of stderr lines of loglit exec
+		"LogStderr": {
+			Group: "LogStderr",
+			Link:  utils.Ptr("ErrorMsg"),
+		},
+		// colors for rainbow groups, picked by hashing the matched text
+		"LogRainbow1":  {Group: "LogRainbow1", Fg: fg("#82AAFF")},
+		"LogRainbow2":  {Group: "LogRainbow2", Fg: fg("#C3E88D")},
+		"LogRainbow3":  {Group: "LogRainbow3", Fg: fg("#FF966C")},
+		"LogRainbow4":  {Group: "LogRainbow4", Fg: fg("#C099FF")},
+		"LogRainbow5":  {Group: "LogRainbow5", Fg: fg("#86E1FC")},
+		"LogRainbow6":  {Group: "LogRainbow6", Fg: fg("#FFC777")},
+		"LogRainbow7":  {Group: "LogRainbow7", Fg: fg("#FCA7EA")},
+		"LogRainbow8":  {Group: "LogRainbow8", Fg: fg("#4FD6BE")},
+		"LogRainbow9":  {Group: "LogRainbow9", Fg: fg("#FF757F")},
+		"LogRainbow10": {Group: "LogRainbow10", Fg: fg("#65BCFF")},
+		"LogRainbow11": {Group: "LogRainbow11", Fg: fg("#B4F9F8")},
+		"LogRainbow12": {Group: "LogRainbow12", Fg: fg("#F8B486")},
 	},
 }
 
 func GetDefaultTheme() Theme {
-	return DefaultTheme
+	return DefaultTheme.Clone()
+}
+
+// Clone returns a copy of the theme that can be modified without affecting
+// the original.
+func (t Theme) Clone() Theme {
+	highlightMap := make(map[string]*highlight, len(t.HighlightMap))
+	for name, hl := range t.HighlightMap {
+		hlCopy := *hl
+		highlightMap[name] = &hlCopy
+	}
+	return Theme{
+		Name:         t.Name,
+		HighlightMap: highlightMap,
+		linked:       t.linked,
+		colorDepth:   t.colorDepth,
+	}
+}
+
+// Extend returns a copy of the theme with the given highlights inserted.
+func (t Theme) Extend(name string, highlights ...highlight) Theme {
+	th := t.Clone()
+	th.Name = name
+	for _, hl := range highlights {
+		th.Insert(hl)
+	}
+	return th
 }
 
 func (t *Theme) ResolveOneLink(name string) error {
@@ -188,13 +249,36 @@ func (t *Theme) ResolveAllLinks() error {
 	return nil
 }
 
+// SetColorDepth sets the color depth all highlights of the theme, including
+// ones inserted later, are rendered for.
+func (t *Theme) SetColorDepth(depth style.ColorDepth) {
+	t.colorDepth = depth
+	for _, hl := range t.HighlightMap {
+		hl.SetColorDepth(depth)
+	}
+}
+
 func (t *Theme) Insert(hl highlight) {
+	hl.SetColorDepth(t.colorDepth)
 	t.HighlightMap[hl.Group] = &hl
 	if hl.Link != nil {
 		t.linked = false
 	}
 }
 
+// Palette returns the highlights named prefix1, prefix2, ... up to the first
+// missing one.
+func (t *Theme) Palette(prefix string) []*highlight {
+	var palette []*highlight
+	for i := 1; ; i++ {
+		hl, ok := t.HighlightMap[fmt.Sprintf("%s%d", prefix, i)]
+		if !ok {
+			return palette
+		}
+		palette = append(palette, hl)
+	}
+}
+
 func (t *Theme) GetHighlight(group string) (highlight, bool) {
 	hl, ok := t.HighlightMap[group]
 	return *hl, ok
@@ -0,0 +1,131 @@
+package timestamp
+
+import (
+	"strings"
+	"time"
+
+	"github.com/madmaxieee/loglit/internal/proto"
+)
+
+// DefaultWindow is how far into a line a timestamp may start and still be
+// considered leading, leaving room for brackets, levels or host names.
+const DefaultWindow = 48
+
+// maxDateTimeGap is the number of separator characters allowed between a date
+// and the time following it.
+const maxDateTimeGap = 3
+
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+	"2006/01/02",
+	"02-01-2006",
+	"02/01/2006",
+	"Jan 2, 2006",
+	"Jan 2 2006",
+	"Jan 2",
+	"2-Jan-2006",
+	"2 Jan 2006",
+	"01-02",
+	"01/02",
+}
+
+// Parser extracts the leading timestamp of log lines using the LogDate and
+// LogTime patterns of the built-in syntax.
+type Parser struct {
+	datePatterns []proto.Pattern
+	timePatterns []proto.Pattern
+	Window       int
+	// Now is used to fill in the year of dates without one.
+	Now func() time.Time
+}
+
+// NewParser builds a Parser from the LogDate and LogTime entries of
+// syntaxList, other entries are ignored.
+func NewParser(syntaxList []proto.Syntax) *Parser {
+	p := &Parser{Window: DefaultWindow, Now: time.Now}
+	for _, syn := range syntaxList {
+		if !syn.Pattern.HasValue() {
+			continue
+		}
+		switch syn.Group {
+		case "LogDate":
+			p.datePatterns = append(p.datePatterns, syn.Pattern)
+		case "LogTime":
+			p.timePatterns = append(p.timePatterns, syn.Pattern)
+		}
+	}
+	return p
+}
+
+// findLeading returns the earliest, and of those the longest, match of the
+// patterns starting within the window.
+func (p *Parser) findLeading(patterns []proto.Pattern, line string) (int, int, bool) {
+	start, end := -1, -1
+	for _, pattern := range patterns {
+		idx := pattern.FindStringIndex(line)
+		if idx == nil || idx[0] > p.Window {
+			continue
+		}
+		if start == -1 || idx[0] < start || (idx[0] == start && idx[1] > end) {
+			start, end = idx[0], idx[1]
+		}
+	}
+	return start, end, start != -1
+}
+
+// Parse returns the leading timestamp of line. Lines with only a time of day
+// get a zero date, and dates without a year are assumed to be in the current
+// year.
+func (p *Parser) Parse(line string) (time.Time, bool) {
+	var date time.Time
+	hasDate, hasYear, hasClock := false, false, false
+
+	dateStart, dateEnd, ok := p.findLeading(p.datePatterns, line)
+	if ok {
+		text := line[dateStart:dateEnd]
+		for _, layout := range dateLayouts {
+			t, err := time.Parse(layout, text)
+			if err != nil {
+				continue
+			}
+			date, hasDate = t, true
+			hasYear = strings.Contains(layout, "2006")
+			hasClock = strings.Contains(layout, "15")
+			break
+		}
+	}
+	if hasDate && !hasYear {
+		date = date.AddDate(p.Now().Year(), 0, 0)
+	}
+	if hasClock {
+		return date, true
+	}
+
+	var timeStart, timeEnd int
+	if hasDate {
+		// the time has to directly follow the date, e.g. "2023-10-27 10:00:00"
+		timeStart, timeEnd, ok = p.findLeading(p.timePatterns, line[dateEnd:])
+		if !ok || timeStart > maxDateTimeGap {
+			return date, true
+		}
+		timeStart, timeEnd = dateEnd+timeStart, dateEnd+timeEnd
+	} else {
+		timeStart, timeEnd, ok = p.findLeading(p.timePatterns, line)
+		if !ok {
+			return time.Time{}, false
+		}
+	}
+	text := strings.Replace(line[timeStart:timeEnd], ",", ".", 1)
+	clock, err := time.Parse("15:04:05.999999999", text)
+	if err != nil {
+		return date, hasDate
+	}
+
+	return time.Date(
+		date.Year(), date.Month(), date.Day(),
+		clock.Hour(), clock.Minute(), clock.Second(), clock.Nanosecond(),
+		date.Location(),
+	), true
+}
@@ -0,0 +1,69 @@
+package timestamp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/madmaxieee/loglit/internal/config"
+)
+
+func TestParse(t *testing.T) {
+	p := NewParser(config.DefaultConfig.BuiltInSyntax)
+	p.Now = func() time.Time { return time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		line     string
+		expected time.Time
+		ok       bool
+	}{
+		{
+			line:     "2023-10-27T10:00:00.123Z INFO started",
+			expected: time.Date(2023, 10, 27, 10, 0, 0, 123000000, time.UTC),
+			ok:       true,
+		},
+		{
+			line:     "2023-10-27 10:00:01,250 [main] WARN slow",
+			expected: time.Date(2023, 10, 27, 10, 0, 1, 250000000, time.UTC),
+			ok:       true,
+		},
+		{
+			line:     "[2023/10/27 10:00:02] ERROR failed",
+			expected: time.Date(2023, 10, 27, 10, 0, 2, 0, time.UTC),
+			ok:       true,
+		},
+		{
+			line:     "Oct 27 10:00:03 host app[123]: message",
+			expected: time.Date(2024, 10, 27, 10, 0, 3, 0, time.UTC),
+			ok:       true,
+		},
+		{
+			line:     "[10:00:04] [FATAL] hardware fault",
+			expected: time.Date(1, 1, 1, 10, 0, 4, 0, time.UTC),
+			ok:       true,
+		},
+		{
+			line:     "27 Oct 2023 something happened",
+			expected: time.Date(2023, 10, 27, 0, 0, 0, 0, time.UTC),
+			ok:       true,
+		},
+		{
+			line: "    at com.example.Main.run(Main.java:12)",
+			ok:   false,
+		},
+		{
+			line: "a long message that only mentions a time much later in the line, at 10:00:00",
+			ok:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		got, ok := p.Parse(tt.line)
+		if ok != tt.ok {
+			t.Errorf("%q: expected ok=%v, got %v", tt.line, tt.ok, ok)
+			continue
+		}
+		if ok && !got.Equal(tt.expected) {
+			t.Errorf("%q: expected %v, got %v", tt.line, tt.expected, got)
+		}
+	}
+}